@@ -0,0 +1,186 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package apply
+
+import (
+	"fmt"
+
+	"github.com/glitchcrab/sonar/cmd/create"
+	"github.com/glitchcrab/sonar/internal/app"
+	"github.com/glitchcrab/sonar/internal/audit"
+	"github.com/glitchcrab/sonar/internal/k8sclient"
+	"github.com/glitchcrab/sonar/internal/session"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	dryRun        bool
+	filename      string
+	keepOnFailure bool
+	reason        string
+)
+
+func NewCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply creates a Sonar session from a session file",
+		Long: `Apply reads a declarative session file and creates the debugging
+deployment and all supporting resources which it describes.
+
+A session file is a small versioned YAML document:
+
+  apiVersion: sonar.a7d.io/v1alpha1
+  kind: Session
+  metadata:
+    name: netdebug
+    namespace: kube-system
+  spec:
+    image: glitchcrab/ubuntu-debug:v1.0
+    like: deployment/my-app
+    command: ["sleep"]
+    args: ["1h"]
+    mounts:
+      - secret:app-tls:/tls
+      - emptydir:/scratch
+    security:
+      privileged: false
+      privilegeEscalation: false
+      runAsNonRoot: true
+      runAsUser: 1000
+      runAsGroup: 1000
+      unprivilegedPing: true
+    scheduling:
+      nodeName: worker10
+      nodeExec: false
+    networkPolicy:
+      enabled: true
+    ttl: 4h
+    workload: deployment
+
+All spec fields are optional and default to the same values as the
+create command's flags; spec.workload corresponds to --kind
+(deployment, pod or job). metadata.name and metadata.namespace fall
+back to the global --name and --namespace flags.
+
+Global flags:
+
+Run "sonar help" in order to see flags which apply to all subcommands.
+
+Flags:
+
+--filename/-f
+
+Path to the session file.
+
+--dry-run (default: False)
+
+Prints the generated manifests to stdout only.
+
+--keep-on-failure (default: false)
+
+Keeps any resources which were created if the session cannot be fully
+created, instead of rolling them back.
+
+--reason (default: none)
+
+Why the session is being created. Recorded on every created resource.`,
+		Example: `
+"sonar apply -f session.yaml" - creates the session described in
+session.yaml.
+
+"sonar delete --filename session.yaml" - deletes the session described
+in session.yaml.`,
+		RunE: runApplyCommand,
+	}
+
+	command.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "print generated manifests to stdout only")
+	command.Flags().StringVarP(&filename, "filename", "f", "", "path to the session file")
+	command.Flags().BoolVar(&keepOnFailure, "keep-on-failure", false, "keep any created resources if the session cannot be fully created")
+	command.Flags().StringVar(&reason, "reason", "", "reason for creating the session (recorded on all resources)")
+
+	return command
+}
+
+func runApplyCommand(cmd *cobra.Command, args []string) error {
+	// Get the App instance from the command context
+	a, err := app.GetApp(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := a.Globals.RequireSingleContext("apply"); err != nil {
+		return err
+	}
+
+	if filename == "" {
+		return fmt.Errorf("--filename must be provided")
+	}
+
+	// Load and validate the session file.
+	s, err := session.Load(filename)
+	if err != nil {
+		return err
+	}
+
+	globals, err := s.Globals(a.Globals)
+	if err != nil {
+		return err
+	}
+
+	v, err := app.GetViper(cmd)
+	if err != nil {
+		return err
+	}
+
+	opts := s.CreateConfig(globals)
+	opts.AllowedRegistries = v.GetStringSlice("allowed-registries")
+	opts.DryRun = dryRun
+	opts.KeepOnFailure = keepOnFailure
+	opts.Reason = reason
+
+	log.Infof("applying session file: %s", filename)
+
+	// Create a Kubernetes clientset. It is also needed for dry-runs when
+	// copying another workload's environment.
+	var k8sClientSet *kubernetes.Clientset
+	if !opts.DryRun || opts.Like != "" {
+		k8sClientSet, err = k8sclient.New(globals.KubeContext, globals.KubeConfig)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx := a.Context
+
+	if err := create.ResolveLike(k8sClientSet, ctx, &opts); err != nil {
+		return err
+	}
+
+	if err := create.ValidateConfig(&opts); err != nil {
+		return err
+	}
+
+	// Record who created the session, and why.
+	if err := audit.Annotate(k8sClientSet, ctx, globals, &opts); err != nil {
+		return err
+	}
+
+	_, err = create.Resources(k8sClientSet, ctx, opts)
+
+	return err
+}
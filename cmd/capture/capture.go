@@ -0,0 +1,182 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package capture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/glitchcrab/sonar/cmd/create"
+	"github.com/glitchcrab/sonar/internal/audit"
+	"github.com/glitchcrab/sonar/internal/config"
+	"github.com/glitchcrab/sonar/internal/k8sclient"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// podPollInterval is how often a deleted pod is checked for.
+const podPollInterval = time.Second
+
+// capturePod returns the running pod of the capture session, creating the
+// session first if it doesn't exist.
+func capturePod(k8sClientSet *kubernetes.Clientset, ctx context.Context, g config.Globals, opts config.CreateConfig) (*corev1.Pod, error) {
+	selector := fmt.Sprintf("owner=sonar,name=%s", opts.Name)
+
+	pods, err := k8sClientSet.CoreV1().Pods(opts.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+
+	// Reuse the session if it is already running on the node. The session's
+	// bare pod is never restarted, so replace it once it has finished (e.g.
+	// when its sleep ran out); the rest of the session is reused.
+	replaced := false
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != opts.NodeName || pod.DeletionTimestamp != nil {
+			continue
+		}
+
+		switch pod.Status.Phase {
+		case corev1.PodRunning:
+			log.Infof("reusing capture session \"%s/%s\"", opts.Namespace, opts.FullName)
+			return &pod, nil
+		case corev1.PodPending:
+			log.Infof("waiting for capture session \"%s/%s\" to start", opts.Namespace, opts.FullName)
+			return startedPod(k8sClientSet, ctx, opts, selector)
+		case corev1.PodSucceeded, corev1.PodFailed:
+			log.Infof("capture session \"%s/%s\" has finished (%s); replacing its pod", opts.Namespace, opts.FullName, pod.Status.Phase)
+			if err := deletePod(k8sClientSet, ctx, &pod); err != nil {
+				return nil, err
+			}
+			replaced = true
+		}
+	}
+	if len(pods.Items) > 0 && !replaced {
+		return nil, fmt.Errorf("session \"%s/%s\" exists but has no running pod on node %s", opts.Namespace, opts.FullName, opts.NodeName)
+	}
+
+	if !replaced {
+		log.Infof("creating capture session \"%s/%s\" on node %s", opts.Namespace, opts.FullName, opts.NodeName)
+	}
+
+	if err := create.ValidateConfig(&opts); err != nil {
+		return nil, err
+	}
+
+	// Record who created the session, and why.
+	if err := audit.Annotate(k8sClientSet, ctx, g, &opts); err != nil {
+		return nil, err
+	}
+
+	if _, err := create.Resources(k8sClientSet, ctx, opts); err != nil {
+		return nil, err
+	}
+
+	pod, err := startedPod(k8sClientSet, ctx, opts, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("capture session \"%s/%s\" is reused by later captures; run 'sonar destroy --name %s -n %s' to remove it", opts.Namespace, opts.FullName, opts.Name, opts.Namespace)
+
+	return pod, nil
+}
+
+// startedPod waits for the capture session's pod to start and returns it.
+func startedPod(k8sClientSet *kubernetes.Clientset, ctx context.Context, opts config.CreateConfig, selector string) (*corev1.Pod, error) {
+	pod, err := create.WaitForPod(k8sClientSet, ctx, opts.Namespace, selector)
+	if err != nil {
+		return nil, fmt.Errorf("capture session \"%s/%s\" did not start: %w", opts.Namespace, opts.FullName, err)
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		return nil, fmt.Errorf("capture session \"%s/%s\" did not start: pod is %s", opts.Namespace, opts.FullName, pod.Status.Phase)
+	}
+
+	return pod, nil
+}
+
+// deletePod deletes a finished pod and waits for it to be gone, so that a
+// replacement of the same name can be created.
+func deletePod(k8sClientSet *kubernetes.Clientset, ctx context.Context, pod *corev1.Pod) error {
+	var gracePeriod int64
+	err := k8sClientSet.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("pod \"%s/%s\" could not be deleted: %w", pod.Namespace, pod.Name, err)
+	}
+
+	err = wait.PollUntilContextCancel(ctx, podPollInterval, true, func(ctx context.Context) (bool, error) {
+		_, err := k8sClientSet.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+
+		return false, err
+	})
+	if err != nil {
+		return fmt.Errorf("pod \"%s/%s\" was not deleted: %w", pod.Namespace, pod.Name, err)
+	}
+
+	return nil
+}
+
+// runCapture runs tcpdump in the pod and copies the pcap stream to out.
+// tcpdump's diagnostics are copied to stderr. Cancelling the context
+// ends the capture.
+func runCapture(ctx context.Context, k8sClientSet *kubernetes.Clientset, restClient *restclient.Config, pod *corev1.Pod, podCommand []string, out io.Writer) error {
+	options := &corev1.PodExecOptions{
+		Command: podCommand,
+		Stdout:  true,
+		Stderr:  true,
+	}
+
+	executor, err := k8sclient.NewExecutor(k8sClientSet, restClient, pod.Namespace, pod.Name, options)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("capturing on node %s: %v", pod.Spec.NodeName, podCommand)
+
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdout: out,
+		Stderr: os.Stderr,
+	})
+
+	// Interrupting Sonar or reaching the timeout is how a capture ends.
+	if ctx.Err() != nil {
+		log.Infof("capture stopped: %v", context.Cause(ctx))
+		return nil
+	}
+
+	var exitErr utilexec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Errorf("tcpdump failed in pod \"%s/%s\" with exit code %d", pod.Namespace, pod.Name, exitErr.ExitStatus())
+	} else if err != nil {
+		return fmt.Errorf("could not run tcpdump in pod \"%s/%s\": %w", pod.Namespace, pod.Name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,258 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package capture
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/glitchcrab/sonar/internal/app"
+	"github.com/glitchcrab/sonar/internal/completion"
+	"github.com/glitchcrab/sonar/internal/config"
+	"github.com/glitchcrab/sonar/internal/k8sclient"
+	"github.com/glitchcrab/sonar/internal/pcap"
+	"github.com/glitchcrab/sonar/internal/toolkit"
+	"github.com/moby/term"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// captureToolkit provides the image and capabilities of capture sessions.
+const captureToolkit = "net"
+
+var (
+	filter    string
+	image     string
+	iface     string
+	nodeName  string
+	reason    string
+	ttl       time.Duration
+	writeFile string
+)
+
+func NewCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "capture [pod]",
+		Short: "Captures network traffic of a pod or node",
+		Long: `Capture runs tcpdump on the node of the target pod and streams the
+capture, in pcap format, to a local file or to stdout. Traffic is
+captured in the node's network namespace and filtered down to the pod's
+IP address, so the pod itself is left untouched.
+
+Captures run in a capture session on the node, called
+'sonar-capture-<node>', which is created if it doesn't exist yet and
+reused by later captures on the same node. The session runs the image
+of the 'net' toolkit (see "sonar toolkits ls") as a privileged bare pod
+in the host's namespaces, and is removed by "sonar gc" once its TTL has
+expired. Provide --name to use a different session.
+
+The capture runs until Sonar is interrupted (Ctrl-C) or the global
+--timeout expires.
+
+Global flags:
+
+Run "sonar help" in order to see flags which apply to all subcommands.
+
+Flags:
+
+--filter/-f (default: none)
+
+BPF filter expression passed to tcpdump, such as 'tcp port 443'. When
+capturing a pod's traffic it is combined with the pod's IP address.
+
+--interface/-i (default: 'any')
+
+Interface on the node to capture on.
+
+--node (default: none)
+
+Captures all traffic on the node instead of a pod's traffic.
+
+--write/-w (default: '-')
+
+File to write the capture to. '-' writes it to stdout, which must not
+be a terminal.
+
+--image (default: the 'net' toolkit's image)
+
+Image of the capture session. It must provide tcpdump.
+
+--ttl (default: 1h)
+
+How long a newly created capture session lives for.
+
+--reason (default: none)
+
+Why the capture session is being created. Recorded on every created
+resource.`,
+		Example: `
+"sonar capture my-app-6d4cf56db6-xk2lp -w my-app.pcap" - captures the
+traffic of the pod to my-app.pcap.
+
+"sonar capture my-app-6d4cf56db6-xk2lp -f 'tcp port 443' | wireshark -k -i -"
+- streams the pod's HTTPS traffic into Wireshark.
+
+"sonar capture --node worker10 -i eth0 -f 'udp port 53' -w dns.pcap" -
+captures the DNS traffic on worker10's eth0 interface.`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completion.Pods,
+		RunE:              runCaptureCommand,
+	}
+
+	command.Flags().StringVarP(&filter, "filter", "f", "", "BPF filter expression")
+	command.Flags().StringVar(&image, "image", "", "image of the capture session (default: the 'net' toolkit's image)")
+	command.Flags().StringVarP(&iface, "interface", "i", "any", "interface to capture on")
+	command.Flags().StringVar(&nodeName, "node", "", "capture all traffic on the node instead of a pod's traffic")
+	command.Flags().StringVar(&reason, "reason", "", "reason for creating the capture session (recorded on all resources)")
+	command.Flags().DurationVar(&ttl, "ttl", time.Hour, "how long a newly created capture session lives for")
+	command.Flags().StringVarP(&writeFile, "write", "w", "-", "file to write the capture to ('-' for stdout)")
+
+	if err := command.RegisterFlagCompletionFunc("node", completion.Nodes); err != nil {
+		log.Fatalf("failed to register completion for --node: %v", err)
+	}
+
+	return command
+}
+
+func runCaptureCommand(cmd *cobra.Command, args []string) error {
+	// Get the App instance from the command context
+	a, err := app.GetApp(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := a.Globals.RequireSingleContext("capture"); err != nil {
+		return err
+	}
+
+	v, err := app.GetViper(cmd)
+	if err != nil {
+		return err
+	}
+
+	if (len(args) == 0) == (nodeName == "") {
+		return fmt.Errorf("either a pod or --node must be provided")
+	}
+
+	// Refuse to dump binary data into the terminal.
+	if writeFile == "-" && term.IsTerminal(os.Stdout.Fd()) {
+		return fmt.Errorf("refusing to write the capture to a terminal; provide --write or pipe stdout into another program")
+	}
+
+	// Create a Kubernetes clientset.
+	k8sClientSet, err := k8sclient.New(a.Globals.KubeContext, a.Globals.KubeConfig)
+	if err != nil {
+		return err
+	}
+
+	ctx := a.Context
+
+	// Work out where to capture, and what.
+	node := nodeName
+	captureFilter := filter
+	if len(args) > 0 {
+		podName := strings.TrimPrefix(args[0], "pod/")
+		pod, err := k8sClientSet.CoreV1().Pods(a.Globals.Namespace).Get(ctx, podName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("could not get pod \"%s/%s\": %w", a.Globals.Namespace, podName, err)
+		}
+		if pod.Spec.NodeName == "" || pod.Status.PodIP == "" {
+			return fmt.Errorf("pod \"%s/%s\" has not been scheduled or has no IP address yet", pod.Namespace, pod.Name)
+		}
+		if pod.Spec.HostNetwork {
+			log.Warnf("pod \"%s/%s\" uses the host network, so the capture includes all traffic of node %s's address", pod.Namespace, pod.Name, pod.Spec.NodeName)
+		}
+
+		node = pod.Spec.NodeName
+		captureFilter = pcap.PodFilter(pod.Status.PodIP, filter)
+	}
+
+	// Capture sessions are named after the node unless a name was provided.
+	name := pcap.SessionName(node)
+	if v.IsSet("name") {
+		name = a.Globals.Name
+	}
+	globals := config.Globals{
+		ClusterTimeout: a.Globals.ClusterTimeout,
+		KubeConfig:     a.Globals.KubeConfig,
+		KubeContext:    a.Globals.KubeContext,
+		KubeContexts:   a.Globals.KubeContexts,
+		Labels:         make(map[string]string),
+		Name:           name,
+		Namespace:      a.Globals.Namespace,
+	}
+	if err := config.ValidateGlobalConfig(&globals); err != nil {
+		return err
+	}
+
+	catalog, err := toolkit.FromConfig(v)
+	if err != nil {
+		return err
+	}
+	t, err := toolkit.Lookup(catalog, captureToolkit)
+	if err != nil {
+		return err
+	}
+	if image == "" {
+		image = t.Image
+	}
+
+	opts := config.CreateConfig{
+		AllowedRegistries: v.GetStringSlice("allowed-registries"),
+		Capabilities:      t.Capabilities,
+		FullName:          globals.FullName,
+		Image:             image,
+		Kind:              config.KindPod,
+		Labels:            globals.Labels,
+		Name:              globals.Name,
+		Namespace:         globals.Namespace,
+		NodeExec:          true,
+		NodeName:          node,
+		PodArgs:           "24h",
+		PodCommand:        "sleep",
+		Reason:            reason,
+		Toolkit:           captureToolkit,
+		TTL:               ttl,
+	}
+
+	pod, err := capturePod(k8sClientSet, ctx, globals, opts)
+	if err != nil {
+		return err
+	}
+
+	// Write the capture to stdout unless a file was provided.
+	var out io.Writer = os.Stdout
+	if writeFile != "-" {
+		f, err := os.Create(writeFile)
+		if err != nil {
+			return fmt.Errorf("could not create capture file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	// Create a Kubernetes REST client for executing into the pod.
+	restClient, err := k8sclient.NewRestclient(a.Globals.KubeConfig, a.Globals.KubeContext)
+	if err != nil {
+		return err
+	}
+
+	return runCapture(ctx, k8sClientSet, restClient, pod, pcap.Command(iface, captureFilter), out)
+}
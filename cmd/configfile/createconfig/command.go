@@ -57,8 +57,7 @@ func runCreateCommand(cmd *cobra.Command, args []string) error {
 	// Find the user's home dir
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		fmt.Println("could not establish user's home dir")
-		return err
+		return fmt.Errorf("could not establish user's home dir: %w", err)
 	}
 
 	path := filepath.Join(homeDir, defaultConfigFilePath)
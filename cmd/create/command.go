@@ -18,30 +18,60 @@ package create
 import (
 	"context"
 	"errors"
-	"log"
+	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/glitchcrab/sonar/internal/app"
+	"github.com/glitchcrab/sonar/internal/audit"
+	"github.com/glitchcrab/sonar/internal/completion"
 	"github.com/glitchcrab/sonar/internal/config"
 	"github.com/glitchcrab/sonar/internal/k8sclient"
+	"github.com/glitchcrab/sonar/internal/report"
+	"github.com/glitchcrab/sonar/internal/toolkit"
+	"github.com/glitchcrab/sonar/internal/types"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
 var (
+	clusterRole         string
+	copyPullSecret      string
 	dryRun              bool
+	env                 []string
+	envFrom             []string
 	image               string
+	imagePullPolicy     string
+	imagePullSecrets    []string
+	keepOnFailure       bool
+	kind                string
+	like                string
+	mounts              []string
 	networkPolicy       bool
 	nodeExec            bool
 	nodeName            string
+	noToken             bool
+	output              string
 	podArgs             string
 	podCommand          string
 	podGroup            int64
 	podUser             int64
 	privileged          bool
 	privilegeEscalation bool
+	readOnly            []string
+	reason              string
+	role                string
+	scripts             []string
+	serviceAccount      string
+	toolkitName         string
 	runAsNonRoot        bool
+	ttl                 time.Duration
 	unprivilegedPing    bool
+	view                bool
 )
 
 func NewCommand() *cobra.Command {
@@ -57,9 +87,9 @@ privileges to create the necessary resources.
 All flags are optional as defaults are provided.
 
 Note: it is safe to run "sonar create" multiple times; if a resource
-already exists then it will be skipped. For example, this can be used
-to add a NetworkPolicy to an existing Sonar deployment which was
-created without it.
+already exists then it will be skipped (and never rolled back). For
+example, this can be used to add a NetworkPolicy to an existing Sonar
+deployment which was created without it.
 
 Global flags:
 
@@ -67,14 +97,73 @@ Run "sonar help" in order to see flags which apply to all subcommands.
 
 Flags:
 
+--cluster-role (default: none)
+
+Binds an existing ClusterRole to the session's ServiceAccount within the
+session's namespace.
+
+--copy-pull-secret (default: none)
+
+Copies an image pull secret from another namespace, given as
+<namespace>/<name>, into the session's namespace and adds it to the
+pod's image pull secrets. The copy is owned by the session and deleted
+along with it.
+
 --dry-run (default: False)
 
 Prints the generated manifests to stdout only.
 
+--output/-o (default: none)
+
+Prints a result document in the provided format ('json' or 'yaml')
+instead of only logging what happened. It lists each resource of the
+session with the action taken on it (created, existed, skipped, failed
+or, if it was rolled back, deleted) and any error. The document's
+apiVersion is the schema version. Cannot be combined with --dry-run.
+
+--env (default: none)
+
+Sets an environment variable in the container, as KEY=VALUE (e.g.
+'HTTP_PROXY=http://proxy:3128'). May be provided multiple times.
+
+--env-from (default: none)
+
+Sets environment variables from every key of a ConfigMap or Secret in
+the session's namespace, as configmap/<name> or secret/<name>. May be
+provided multiple times.
+
 --image (default: 'busybox:latest')
 
 Name of the image to use. Image names may be provided with or without a
-tag; if no tag is detected then 'latest' is automatically used.
+tag or digest; if neither is provided then 'latest' is automatically
+used. If the config file sets 'allowed-registries', the image must come
+from one of the listed registries (e.g. 'registry.example.com:5000') or
+repository prefixes (e.g. 'ghcr.io/example').
+
+--image-pull-policy (default: none)
+
+The container's image pull policy: Always, IfNotPresent or Never.
+
+--image-pull-secret (default: none)
+
+Image pull secret(s) in the session's namespace to pull the image with.
+
+--keep-on-failure (default: false)
+
+Creation is all-or-nothing: if any resource cannot be created, the
+resources created so far are deleted again in reverse order. Set this
+flag to keep them, e.g. to debug the failure itself.
+
+--kind (default: 'deployment')
+
+The kind of workload which runs the debug container:
+
+  deployment - a long-lived session which is rescheduled if its pod is lost
+  pod        - a bare pod for a quick session; it is never rescheduled or
+               restarted
+  job        - runs the command to completion (e.g. a diagnostic script).
+               Sonar waits for the job, streams its logs and exits with an
+               error if it failed
 
 --pod-cmd (default: 'sleep')
 
@@ -91,7 +180,33 @@ User ID to run the container as (set in deployment's SecurityContext).
 --privileged (default: false)
 
 Allow the pod to run as a privileged pod; must be provided at the same
-time as --podsecuritypolicy to have any effect.
+time as --podsecuritypolicy to have any effect. Implies
+--privilege-escalation.
+
+--like (default: none)
+
+Makes the debug pod look like an existing workload in the session's
+namespace, given as pod/<name>, deployment/<name> or statefulset/<name>.
+The env, envFrom and volume mounts of its first container are copied,
+along with its volumes, ServiceAccount (unless it is the 'default'
+ServiceAccount or --service-account is provided), node selector and
+tolerations. Probes, lifecycle hooks, resources and the image and
+command are not copied. The copied fields are logged, including for
+dry-runs. Copied volumes whose names or mount paths clash with --mount,
+--script or --node-exec are rejected before anything is created.
+
+--mount (default: none)
+
+Mounts a volume from the session's namespace into the container. May be
+provided multiple times. Supported forms are:
+
+  configmap:<name>:<path>   - mounts a ConfigMap (read-only)
+  secret:<name>:<path>      - mounts a Secret (read-only)
+  pvc:<name>:<path>[:ro]    - mounts a PersistentVolumeClaim
+  emptydir:<path>           - mounts an empty scratch volume
+
+Sonar checks that every source exists, and warns if a ReadWriteOnce PVC
+is already attached to a pod on another node.
 
 --networkpolicy (default: false)
 
@@ -101,13 +216,68 @@ Apply a NetworkPolicy which allows all ingress and egress traffic.
 
 Attempt to schedule the pod on the named node.
 
+--no-token (default: false)
+
+Disables automounting of the ServiceAccount's token into the pod. Cannot
+be combined with any of the role flags.
+
 --node-exec (default: false)
 
+--read-only (default: none)
+
+Generates a Role which grants get, list and watch on the provided
+resources (e.g. 'pods,services,deployments.apps') and binds it to the
+session's ServiceAccount.
+
+--reason (default: none)
+
+Why the session is being created. Recorded on every created resource
+alongside the creating user, the creation time, the Sonar version and
+the effective options.
+
+--role (default: none)
+
+Binds an existing Role in the session's namespace to the session's
+ServiceAccount.
+
+--script (default: none)
+
+Mounts a local script into the container at /sonar/scripts/<file name>,
+through a ConfigMap which belongs to the session, so that it survives
+reconnects and can be run with "sonar exec -- /sonar/scripts/<file>".
+May be provided multiple times. To run a script once without mounting
+it, see "sonar run".
+
+--service-account (default: none)
+
+Runs the debug pod as an existing ServiceAccount in the session's
+namespace (e.g. an application's) instead of creating one. Sonar does
+not own the ServiceAccount, so destroy leaves it alone. Cannot be
+combined with any of the role flags.
+
+--toolkit (default: none)
+
+Uses a debug toolkit from the catalog (see "sonar toolkits ls"), which
+sets the image, command, args and the capabilities which the toolkit's
+tools need. Explicitly provided --image, --pod-command and --pod-args
+flags take precedence. The flags which the toolkit recommends are
+logged.
+
+--ttl (default: none)
+
+How long the session should live for (e.g. '4h'). The expiry time is
+recorded on every created resource.
+
 --unprivileged-ping (default: false)
 
 Sets the 'net.ipv4.ping_group_range' sysctl to allow ping to be used
 without root privileges.
 
+--view (default: false)
+
+Preset which binds the built-in 'view' ClusterRole, allowing the pod to
+read most resources in the session's namespace.
+
 Examples:
 
 Create a privileged pod in the node's PID & network namespaces. A node
@@ -133,6 +303,35 @@ all ingress and traffic to the Sonar pod.
     --pod-userid 0" - creates a pod with root access to the node named
 worker2.
 
+"sonar create --view" - lets the debug pod read most resources in its
+namespace through the API.
+
+"sonar create --read-only pods,endpointslices.discovery.k8s.io" - lets
+the debug pod read only pods and EndpointSlices.
+
+"sonar create --service-account my-app --namespace my-app" - runs the
+debug pod with the permissions of the 'my-app' ServiceAccount.
+
+"sonar create --namespace my-app --mount secret:my-app-tls:/tls \
+    --mount pvc:my-app-data:/data:ro" - mounts the app's TLS secret and
+its data volume (read-only) into the debug pod.
+
+"sonar create --namespace my-app --like deployment/my-app \
+    --image glitchcrab/ubuntu-debug:latest" - creates a debug pod with
+the same env, volumes and identity as the 'my-app' deployment.
+
+"sonar create --image registry.example.com/debug:v1 \
+    --copy-pull-secret registry/pull-secret --env HTTP_PROXY=http://proxy:3128"
+- pulls a private debug image using a temporary copy of the registry's
+pull secret and sets a proxy.
+
+"sonar create --toolkit net" - creates a network debugging session
+using the 'net' toolkit.
+
+"sonar create --kind job --pod-cmd nslookup --pod-args kubernetes" -
+runs a one-off lookup, streams its output and reports whether it
+succeeded.
+
 "sonar create --dry-run" - prints the generated Kubernetes manifests
 to stdout without applying them to the cluster.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -145,8 +344,18 @@ to stdout without applying them to the cluster.`,
 		},
 	}
 
+	command.Flags().StringVar(&clusterRole, "cluster-role", "", "bind an existing ClusterRole to the ServiceAccount in the session's namespace")
+	command.Flags().StringVar(&copyPullSecret, "copy-pull-secret", "", "copy an image pull secret (<namespace>/<name>) into the session's namespace for the session's lifetime")
 	command.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "print generated manifests to stdout only")
+	command.Flags().StringArrayVar(&env, "env", nil, "set an environment variable in the container (KEY=VALUE)")
+	command.Flags().StringArrayVar(&envFrom, "env-from", nil, "set environment variables from a ConfigMap or Secret (configmap/<name> or secret/<name>)")
 	command.Flags().StringVarP(&image, "image", "i", "busybox:latest", "image name (e.g. glitchcrab/ubuntu-debug:latest)")
+	command.Flags().StringVar(&imagePullPolicy, "image-pull-policy", "", "image pull policy (Always, IfNotPresent or Never)")
+	command.Flags().StringSliceVar(&imagePullSecrets, "image-pull-secret", nil, "image pull secret(s) in the session's namespace")
+	command.Flags().BoolVar(&keepOnFailure, "keep-on-failure", false, "keep any created resources if the session cannot be fully created")
+	command.Flags().StringVar(&kind, "kind", "deployment", "kind of workload to run the debug container in (deployment, pod or job)")
+	command.Flags().StringVar(&like, "like", "", "copy the environment of an existing workload (pod/<name>, deployment/<name> or statefulset/<name>)")
+	command.Flags().StringArrayVar(&mounts, "mount", nil, "mount a volume into the container (configmap:<name>:<path>, secret:<name>:<path>, pvc:<name>:<path>[:ro] or emptydir:<path>)")
 	command.Flags().BoolVar(&networkPolicy, "networkpolicy", false, "create NetworkPolicy")
 	command.Flags().BoolVar(&nodeExec, "node-exec", false, "spawn a container with root access to the node")
 	command.Flags().StringVarP(&nodeName, "node-name", "", "", "node name to attempt to schedule the pod on")
@@ -156,8 +365,31 @@ to stdout without applying them to the cluster.`,
 	command.Flags().Int64VarP(&podUser, "pod-userid", "u", 1000, "userID to run the pod as")
 	command.Flags().BoolVar(&privileged, "privileged", false, "run a privileged container (assumes userID of 0)")
 	command.Flags().BoolVar(&privilegeEscalation, "privilege-escalation", false, "allow privilege escalation")
+	command.Flags().BoolVar(&noToken, "no-token", false, "do not mount the ServiceAccount's token into the pod")
+	command.Flags().StringVarP(&output, "output", "o", "", "print a result document (json|yaml)")
+	command.Flags().StringSliceVar(&readOnly, "read-only", nil, "bind a generated read-only Role for the resources (e.g. pods,deployments.apps)")
+	command.Flags().StringVar(&reason, "reason", "", "reason for creating the session (recorded on all resources)")
+	command.Flags().StringVar(&role, "role", "", "bind an existing Role to the ServiceAccount")
 	command.Flags().BoolVar(&runAsNonRoot, "non-root", true, "run the container as non-root (assumes userID of 0)")
+	command.Flags().StringArrayVar(&scripts, "script", nil, "mount a local script into the container at "+config.ScriptsPath)
+	command.Flags().StringVar(&serviceAccount, "service-account", "", "run the pod as an existing ServiceAccount instead of creating one")
+	command.Flags().StringVar(&toolkitName, "toolkit", "", "use a debug toolkit from the catalog (see 'sonar toolkits ls')")
+	command.Flags().DurationVar(&ttl, "ttl", 0, "how long the session should live for (e.g. 4h)")
 	command.Flags().BoolVar(&unprivilegedPing, "unprivileged-ping", false, "allow a non-root user to use ping")
+	command.Flags().BoolVar(&view, "view", false, "bind the built-in 'view' ClusterRole to the ServiceAccount")
+
+	// Add completions for flags
+	for flag, fn := range map[string]completion.Func{
+		"image-pull-policy": completion.Values("Always", "IfNotPresent", "Never"),
+		"kind":              completion.Values(config.Kinds...),
+		"node-name":         completion.Nodes,
+		"output":            completion.Values(report.FormatJSON, report.FormatYAML),
+		"toolkit":           completion.Toolkits,
+	} {
+		if err := command.RegisterFlagCompletionFunc(flag, fn); err != nil {
+			log.Fatalf("failed to register completion for --%s: %v", flag, err)
+		}
+	}
 
 	return command
 }
@@ -169,6 +401,10 @@ func runCreateCommand(command *cobra.Command, args []string) error {
 		return err
 	}
 
+	if err := a.Globals.RequireSingleContext("create"); err != nil {
+		return err
+	}
+
 	v, err := app.GetViper(command)
 	if err != nil {
 		return err
@@ -177,69 +413,314 @@ func runCreateCommand(command *cobra.Command, args []string) error {
 	// Wire in Viper.
 	v, err = updateViperConfig(command, v)
 	if err != nil {
-		log.Fatalf("Error updating Viper config: %v", err)
+		return fmt.Errorf("error updating Viper config: %w", err)
+	}
+
+	if err := report.ValidateFormat(output); err != nil {
+		return err
+	}
+	if output != "" && dryRun {
+		return fmt.Errorf("--output cannot be used with --dry-run")
+	}
+
+	// --view is a preset for the built-in read-only ClusterRole.
+	if view {
+		if clusterRole != "" {
+			return fmt.Errorf("only one of --cluster-role, --role, --view and --read-only may be provided")
+		}
+		clusterRole = "view"
+	}
+
+	parsedMounts, err := config.ParseMounts(mounts)
+	if err != nil {
+		return err
+	}
+
+	parsedScripts, err := config.ReadScripts(scripts)
+	if err != nil {
+		return err
 	}
 
 	opts := config.CreateConfig{
+		AllowedRegistries:   v.GetStringSlice("allowed-registries"),
+		ClusterRole:         clusterRole,
+		CopyPullSecret:      v.GetString("copy-pull-secret"),
 		DryRun:              dryRun,
+		Env:                 v.GetStringSlice("env"),
+		EnvFrom:             v.GetStringSlice("env-from"),
 		FullName:            a.Globals.FullName,
 		Image:               v.GetString("image"),
+		ImagePullPolicy:     v.GetString("image-pull-policy"),
+		ImagePullSecrets:    v.GetStringSlice("image-pull-secret"),
+		KeepOnFailure:       keepOnFailure,
+		Kind:                v.GetString("kind"),
 		Labels:              a.Globals.Labels,
+		Like:                like,
+		Mounts:              parsedMounts,
 		Name:                a.Globals.Name,
 		Namespace:           a.Globals.Namespace,
 		NetworkPolicy:       v.GetBool("networkpolicy"),
 		NodeExec:            nodeExec,
 		NodeName:            nodeName,
+		NoToken:             v.GetBool("no-token"),
 		NonRoot:             v.GetBool("non-root"),
+		Output:              output,
 		PodArgs:             v.GetString("pod-args"),
 		PodCommand:          v.GetString("pod-command"),
 		PodGroup:            v.GetInt64("pod-groupid"),
 		PodUser:             v.GetInt64("pod-userid"),
 		Privileged:          v.GetBool("privileged"),
 		PrivilegeEscalation: v.GetBool("privilege-escalation"),
+		ReadOnlyResources:   readOnly,
+		Reason:              reason,
+		Role:                role,
+		Scripts:             parsedScripts,
+		ServiceAccount:      serviceAccount,
+		Toolkit:             toolkitName,
+		TTL:                 v.GetDuration("ttl"),
 		UnprivilegedPing:    v.GetBool("unprivileged-ping"),
 	}
 
-	if err := validateCreateConfig(command, &opts); err != nil {
-		return err
+	// Use the image, command and capabilities of the selected toolkit.
+	if toolkitName != "" {
+		if err := applyToolkit(command, v, &opts); err != nil {
+			return err
+		}
 	}
 
-	// Create a Kubernetes clientset.
+	// Create a Kubernetes clientset. It is also needed for dry-runs when
+	// copying another workload's environment.
 	var k8sClientSet *kubernetes.Clientset
-	if !opts.DryRun {
+	if !opts.DryRun || opts.Like != "" {
 		k8sClientSet, err = k8sclient.New(a.Globals.KubeContext, a.Globals.KubeConfig)
 		if err != nil {
 			return err
 		}
 	}
 
-	ctx := context.TODO()
+	ctx := a.Context
+
+	if err := ResolveLike(k8sClientSet, ctx, &opts); err != nil {
+		return err
+	}
+
+	if err := ValidateConfig(&opts); err != nil {
+		return err
+	}
+
+	// Record who created the session, and why.
+	if err := audit.Annotate(k8sClientSet, ctx, a.Globals, &opts); err != nil {
+		return err
+	}
+
+	results, err := Resources(k8sClientSet, ctx, opts)
+	if output == "" {
+		return err
+	}
+
+	// Report what happened to each resource, even if creation failed.
+	cluster, _ := k8sclient.ContextName(a.Globals.KubeConfig, a.Globals.KubeContext)
+	result := report.New("create")
+	result.Add(cluster, results)
+	result.AddError(err)
+
+	return errors.Join(err, result.Write(os.Stdout, output))
+}
+
+// createStep creates a single session resource. It reports whether the
+// resource was created by this invocation. name defaults to the session's
+// full name.
+type createStep struct {
+	kind   string
+	name   string
+	create func(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.CreateConfig) (bool, error)
+}
+
+// Resources creates all of the resources which make up a Sonar session.
+// Every resource is owned by the session's anchor ConfigMap. Creation is
+// all-or-nothing: if any resource fails, the resources which were created
+// by this invocation are deleted again in reverse order
+// (unless opts.KeepOnFailure is set). If the context was cancelled, the
+// user is asked before rolling back. The outcome for each resource is
+// returned, even if creation failed.
+func Resources(k8sClientSet *kubernetes.Clientset, ctx context.Context, opts config.CreateConfig) ([]types.ResourceResult, error) {
+	// Make sure that a borrowed ServiceAccount exists before creating
+	// anything.
+	if opts.ServiceAccount != "" && !opts.DryRun {
+		if _, err := k8sClientSet.CoreV1().ServiceAccounts(opts.Namespace).Get(ctx, opts.ServiceAccount, metav1.GetOptions{}); err != nil {
+			return nil, fmt.Errorf("serviceaccount \"%s/%s\" could not be found: %w", opts.Namespace, opts.ServiceAccount, err)
+		}
+		log.Infof("using existing serviceaccount \"%s/%s\"", opts.Namespace, opts.ServiceAccount)
+	}
+
+	// Make sure that a Role or ClusterRole to be bound exists before
+	// creating anything.
+	if !opts.DryRun {
+		if err := checkRole(k8sClientSet, ctx, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	// Make sure that everything which is mounted exists.
+	if !opts.DryRun {
+		if err := checkMounts(k8sClientSet, ctx, opts); err != nil {
+			return nil, err
+		}
+	}
 
-	var errs []error
+	var created []types.SessionResource
+	var results []types.ResourceResult
 
-	// Create the ServiceAccount
-	saErr := createServiceAccount(k8sClientSet, ctx, opts)
-	if saErr != nil {
-		errs = append(errs, saErr)
+	// Create the anchor first, as it owns every other resource.
+	anchorResource := types.SessionResource{Kind: "configmap", Namespace: opts.Namespace, Name: opts.FullName}
+	anchor, ok, err := createAnchor(k8sClientSet, ctx, opts)
+	if err != nil {
+		return []types.ResourceResult{{SessionResource: anchorResource, Action: types.ActionFailed, Err: err}}, err
+	}
+	if ok {
+		created = append(created, anchorResource)
+	}
+	results = append(results, types.ResourceResult{SessionResource: anchorResource, Action: createAction(opts, ok)})
+	if anchor != nil {
+		opts.OwnerReferences = []metav1.OwnerReference{ownerReference(anchor)}
+	}
+
+	// Create the session's own ServiceAccount unless one is borrowed.
+	var steps []createStep
+	if opts.ServiceAccount == "" {
+		steps = append(steps, createStep{kind: "serviceaccount", create: createServiceAccount})
+	}
+
+	// Ship any scripts before the pod needs them.
+	if len(opts.Scripts) > 0 {
+		steps = append(steps, createStep{kind: "configmap", name: opts.ScriptsConfigMapName(), create: createScripts})
+	}
+
+	// Copy the image pull secret before the pod needs it.
+	if opts.CopyPullSecret != "" {
+		steps = append(steps, createStep{kind: "secret", name: opts.CopiedPullSecretName(), create: createPullSecret})
+	}
+
+	// Generate a read-only Role if requested, and bind the requested role.
+	if len(opts.ReadOnlyResources) > 0 {
+		steps = append(steps, createStep{kind: "role", create: createRole})
+	}
+	if opts.BindsRole() {
+		steps = append(steps, createStep{kind: "rolebinding", create: createRoleBinding})
 	}
 
 	// If set, create a NetworkPolicy
 	if opts.NetworkPolicy {
-		npErr := createNetworkPolicy(k8sClientSet, ctx, opts)
-		if npErr != nil {
-			errs = append(errs, npErr)
+		steps = append(steps, createStep{kind: "networkpolicy", create: createNetworkPolicy})
+	}
+
+	// Run the debug container in the requested kind of workload.
+	switch opts.Kind {
+	case config.KindJob:
+		steps = append(steps, createStep{kind: "job", create: createJob})
+	case config.KindPod:
+		steps = append(steps, createStep{kind: "pod", create: createPod})
+	default:
+		steps = append(steps, createStep{kind: "deployment", create: createDeployment})
+	}
+
+	for _, step := range steps {
+		name := step.name
+		if name == "" {
+			name = opts.FullName
+		}
+		r := types.SessionResource{Kind: step.kind, Namespace: opts.Namespace, Name: name}
+
+		ok, err := step.create(k8sClientSet, ctx, opts)
+		if err != nil {
+			results = append(results, types.ResourceResult{SessionResource: r, Action: types.ActionFailed, Err: err})
+			rolledBack, rollbackErr := handleFailure(k8sClientSet, ctx, opts, created)
+			return mergeResults(results, rolledBack), errors.Join(err, rollbackErr)
+		}
+
+		if ok {
+			created = append(created, r)
+		}
+		results = append(results, types.ResourceResult{SessionResource: r, Action: createAction(opts, ok)})
+	}
+
+	// Jobs run to completion, so report how they got on.
+	if opts.Kind == config.KindJob && !opts.DryRun {
+		return results, waitForJob(k8sClientSet, ctx, opts)
+	}
+
+	return results, nil
+}
+
+// createAction returns the action taken on a resource, given whether it
+// was created by this invocation.
+func createAction(opts config.CreateConfig, created bool) string {
+	switch {
+	case opts.DryRun:
+		return types.ActionSkipped
+	case created:
+		return types.ActionCreated
+	default:
+		return types.ActionExisted
+	}
+}
+
+// mergeResults replaces the outcome of any resources which were rolled
+// back with the outcome of their deletion.
+func mergeResults(results, rolledBack []types.ResourceResult) []types.ResourceResult {
+	for _, rb := range rolledBack {
+		for i, r := range results {
+			if r.Kind == rb.Kind && r.Namespace == rb.Namespace && r.Name == rb.Name {
+				results[i].Action = rb.Action
+				results[i].Err = rb.Err
+			}
 		}
 	}
 
-	// Create the Deployment
-	deployErr := createDeployment(k8sClientSet, ctx, opts)
-	if deployErr != nil {
-		errs = append(errs, deployErr)
+	return results
+}
+
+// applyToolkit applies the selected toolkit to opts. Flags which were
+// explicitly provided take precedence over the toolkit.
+func applyToolkit(command *cobra.Command, v *viper.Viper, opts *config.CreateConfig) error {
+	catalog, err := toolkit.FromConfig(v)
+	if err != nil {
+		return err
+	}
+
+	t, err := toolkit.Lookup(catalog, opts.Toolkit)
+	if err != nil {
+		return err
+	}
+
+	if !command.Flags().Changed("image") {
+		opts.Image = t.Image
+	}
+	if t.Command != "" && !command.Flags().Changed("pod-command") {
+		opts.PodCommand = t.Command
+	}
+	if t.Args != "" && !command.Flags().Changed("pod-args") {
+		opts.PodArgs = t.Args
+	}
+	opts.Capabilities = append(opts.Capabilities, t.Capabilities...)
+
+	// Run as root if the toolkit needs to, unless the user was explicitly
+	// set.
+	if t.Root {
+		if !command.Flags().Changed("pod-userid") {
+			opts.PodUser = 0
+		}
+		if !command.Flags().Changed("non-root") {
+			opts.NonRoot = false
+		}
+	}
+	if len(t.Capabilities) > 0 && (opts.PodUser != 0 || opts.NonRoot) {
+		log.Warnf("toolkit %q adds capabilities (%s) which have no effect unless the container runs as root (--pod-userid 0 --non-root=false)", t.Name, strings.Join(t.Capabilities, ","))
 	}
 
-	// If there were any validation errors, return them as a single error.
-	if len(errs) > 0 {
-		return errors.Join(errs...)
+	log.Infof("using toolkit %q (%s)", t.Name, t.Image)
+	if len(t.Flags) > 0 {
+		log.Infof("toolkit %q recommends: %s", t.Name, strings.Join(t.Flags, " "))
 	}
 
 	return nil
@@ -250,8 +731,15 @@ func updateViperConfig(command *cobra.Command, v *viper.Viper) (*viper.Viper, er
 	// Bind some more flags to Viper.
 
 	flagsToBind := []string{
+		"copy-pull-secret",
+		"env",
+		"env-from",
 		"image",
+		"image-pull-policy",
+		"image-pull-secret",
+		"kind",
 		"networkpolicy",
+		"no-token",
 		"pod-args",
 		"pod-command",
 		"pod-groupid",
@@ -259,6 +747,7 @@ func updateViperConfig(command *cobra.Command, v *viper.Viper) (*viper.Viper, er
 		"privileged",
 		"privilege-escalation",
 		"non-root",
+		"ttl",
 		"unprivileged-ping",
 	}
 
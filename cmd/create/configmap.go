@@ -0,0 +1,153 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package create
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/glitchcrab/sonar/internal/config"
+	"github.com/glitchcrab/sonar/internal/logging"
+	"github.com/glitchcrab/sonar/internal/types"
+	"github.com/glitchcrab/sonar/internal/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// createAnchor creates the session's anchor ConfigMap, which owns every
+// other resource in the session so that deleting it cascades. It returns
+// the anchor (nil for dry-runs) and whether it was created by this
+// invocation.
+func createAnchor(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.CreateConfig) (*corev1.ConfigMap, bool, error) {
+	// Define the ConfigMap
+	cm := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: o.Annotations,
+			Labels:      o.Labels,
+			Name:        o.FullName,
+			Namespace:   o.Namespace,
+		},
+	}
+
+	// If dry-run is enabled, print the manifest and return
+	if o.DryRun {
+		if err := utils.PrintManifestYAML(cm); err != nil {
+			return nil, false, fmt.Errorf("configmap \"%s/%s\" manifest generation failed: %v", o.Namespace, o.Name, err)
+		}
+		return nil, false, nil
+	}
+
+	anchor, err := k8sClientSet.CoreV1().ConfigMaps(o.Namespace).Create(ctx, cm, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		// Reuse the existing anchor so that new resources join the session.
+		anchor, err = k8sClientSet.CoreV1().ConfigMaps(o.Namespace).Get(ctx, o.FullName, metav1.GetOptions{})
+		if err != nil {
+			return nil, false, fmt.Errorf("configmap \"%s/%s\" could not be retrieved: %w", o.Namespace, o.Name, err)
+		}
+		if err := checkAnchor(anchor, o); err != nil {
+			return nil, false, err
+		}
+		logging.Resource(ctx, o.Namespace, "configmap", o.FullName, types.ActionExisted).
+			Infof("configmap \"%s/%s\" already exists; skipping", o.Namespace, o.Name)
+		return anchor, false, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("configmap \"%s/%s\" was not created: %w", o.Namespace, o.Name, err)
+	}
+
+	logging.Resource(ctx, o.Namespace, "configmap", o.FullName, types.ActionCreated).
+		Infof("configmap \"%s/%s\" created", o.Namespace, o.Name)
+
+	return anchor, true, nil
+}
+
+// checkAnchor returns an error if an existing ConfigMap is not the
+// session's anchor. Adopting an unrelated ConfigMap would make the session
+// depend on it, so deleting it would garbage-collect the session.
+func checkAnchor(anchor *corev1.ConfigMap, o config.CreateConfig) error {
+	if anchor.Labels["owner"] != "sonar" {
+		return fmt.Errorf("configmap \"%s/%s\" already exists and is not owned by Sonar", anchor.Namespace, anchor.Name)
+	}
+
+	// Sessions created by older versions of Sonar are not labelled with a
+	// session ID.
+	if id, ok := anchor.Labels[config.LabelSession]; ok && id != o.Labels[config.LabelSession] {
+		return fmt.Errorf("configmap \"%s/%s\" already exists and belongs to session %s", anchor.Namespace, anchor.Name, id)
+	}
+
+	return nil
+}
+
+// createScripts creates a ConfigMap holding the session's scripts, so
+// that they survive reconnects and restarts of the debug container.
+func createScripts(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.CreateConfig) (bool, error) {
+	name := o.ScriptsConfigMapName()
+
+	// Define the ConfigMap
+	cm := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations:     o.Annotations,
+			Labels:          o.Labels,
+			Name:            name,
+			Namespace:       o.Namespace,
+			OwnerReferences: o.OwnerReferences,
+		},
+		Data: o.Scripts,
+	}
+
+	// If dry-run is enabled, print the manifest and return
+	if o.DryRun {
+		if err := utils.PrintManifestYAML(cm); err != nil {
+			return false, fmt.Errorf("configmap \"%s/%s\" manifest generation failed: %v", o.Namespace, name, err)
+		}
+		return false, nil
+	}
+
+	_, err := k8sClientSet.CoreV1().ConfigMaps(o.Namespace).Create(ctx, cm, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		// Leave existing resources alone so that create can be re-run.
+		logging.Resource(ctx, o.Namespace, "configmap", name, types.ActionExisted).
+			Infof("configmap \"%s/%s\" already exists; skipping", o.Namespace, name)
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("configmap \"%s/%s\" was not created: %w", o.Namespace, name, err)
+	}
+
+	logging.Resource(ctx, o.Namespace, "configmap", name, types.ActionCreated).
+		Infof("configmap \"%s/%s\" created", o.Namespace, name)
+
+	return true, nil
+}
+
+// ownerReference returns a reference to the anchor for use by the other
+// resources in the session.
+func ownerReference(anchor *corev1.ConfigMap) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Name:       anchor.Name,
+		UID:        anchor.UID,
+	}
+}
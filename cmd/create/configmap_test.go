@@ -0,0 +1,66 @@
+package create
+
+import (
+	"testing"
+
+	"github.com/glitchcrab/sonar/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckAnchor(t *testing.T) {
+	o := config.CreateConfig{
+		FullName:  "sonar-test",
+		Labels:    map[string]string{"owner": "sonar", config.LabelSession: "0123456789abcdef0123"},
+		Namespace: "default",
+	}
+
+	testCases := []struct {
+		name    string
+		labels  map[string]string
+		wantErr bool
+	}{
+		{
+			name:   "test same session",
+			labels: map[string]string{"owner": "sonar", config.LabelSession: "0123456789abcdef0123"},
+		},
+		{
+			name:   "test session without id",
+			labels: map[string]string{"owner": "sonar", "name": "test"},
+		},
+		{
+			name:    "test other session",
+			labels:  map[string]string{"owner": "sonar", config.LabelSession: "fedcba9876543210fedc"},
+			wantErr: true,
+		},
+		{
+			name:    "test not owned by sonar",
+			labels:  map[string]string{"app": "test"},
+			wantErr: true,
+		},
+		{
+			name:    "test no labels",
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			anchor := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:    testCase.labels,
+					Name:      "sonar-test",
+					Namespace: "default",
+				},
+			}
+
+			err := checkAnchor(anchor, o)
+			if testCase.wantErr && err == nil {
+				t.Error("expected error, got nil")
+			}
+			if !testCase.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -18,68 +18,21 @@ package create
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	"github.com/glitchcrab/sonar/internal/config"
+	"github.com/glitchcrab/sonar/internal/logging"
+	"github.com/glitchcrab/sonar/internal/types"
 	"github.com/glitchcrab/sonar/internal/utils"
-	log "github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-var (
-	hostIPC        = false
-	hostNet        = false
-	hostPID        = false
-	replicas int32 = 1
-	sysctls        = []corev1.Sysctl{}
-)
-
-func createDeployment(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.CreateConfig) error {
-	// Create container in the host namespaces if node-exec is set.
-	if o.NodeExec {
-		hostIPC = true
-		hostNet = true
-		hostPID = true
-	}
-
-	securityContext := &corev1.SecurityContext{
-		Capabilities: &corev1.Capabilities{
-			Drop: []corev1.Capability{"ALL"},
-		},
-		Privileged:               &o.Privileged,
-		RunAsUser:                &o.PodUser,
-		RunAsGroup:               &o.PodGroup,
-		RunAsNonRoot:             &o.NonRoot,
-		AllowPrivilegeEscalation: &o.PrivilegeEscalation,
-		SeccompProfile: &corev1.SeccompProfile{
-			Type: "RuntimeDefault",
-		},
-	}
-
-	// Add sysctl to allow unprivileged users to use ping.
-	if o.UnprivilegedPing {
-		pingGroupRange := corev1.Sysctl{
-			Name:  "net.ipv4.ping_group_range",
-			Value: "0 2147483647",
-		}
-		sysctls = append(sysctls, pingGroupRange)
-	}
-
-	podSecurityContext := &corev1.PodSecurityContext{
-		RunAsUser:    &o.PodUser,
-		RunAsGroup:   &o.PodGroup,
-		RunAsNonRoot: &o.NonRoot,
-		SeccompProfile: &corev1.SeccompProfile{
-			Type: "RuntimeDefault",
-		},
-		Sysctls: sysctls,
-	}
+var replicas int32 = 1
 
+func createDeployment(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.CreateConfig) (bool, error) {
 	// Define the Deployment
 	deployment := &appsv1.Deployment{
 		TypeMeta: metav1.TypeMeta{
@@ -87,109 +40,41 @@ func createDeployment(k8sClientSet *kubernetes.Clientset, ctx context.Context, o
 			Kind:       "Deployment",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Labels:    o.Labels,
-			Name:      o.FullName,
-			Namespace: o.Namespace,
+			Annotations:     o.Annotations,
+			Labels:          o.Labels,
+			Name:            o.FullName,
+			Namespace:       o.Namespace,
+			OwnerReferences: o.OwnerReferences,
 		},
 		Spec: appsv1.DeploymentSpec{
 			Replicas: &replicas,
 			Selector: &metav1.LabelSelector{
 				MatchLabels: o.Labels,
 			},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: o.Labels,
-				},
-				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{
-						{
-							Image: o.Image,
-							Name:  "sonar",
-							Resources: corev1.ResourceRequirements{
-								Limits: corev1.ResourceList{
-									corev1.ResourceCPU:    resource.MustParse("2"),
-									corev1.ResourceMemory: resource.MustParse("250Mi"),
-								},
-								Requests: corev1.ResourceList{
-									corev1.ResourceCPU:    resource.MustParse("200m"),
-									corev1.ResourceMemory: resource.MustParse("50Mi"),
-								},
-							},
-							SecurityContext: securityContext,
-						},
-					},
-					HostIPC:            hostIPC,
-					HostNetwork:        hostNet,
-					HostPID:            hostPID,
-					RestartPolicy:      corev1.RestartPolicyAlways,
-					ServiceAccountName: o.FullName,
-					SecurityContext:    podSecurityContext,
-				},
-			},
+			Template: podTemplate(o, corev1.RestartPolicyAlways),
 		},
 	}
 
-	// Update the deployment's command if one was provided.
-	if o.PodCommand != "" {
-		command := strings.Fields(o.PodCommand)
-		deployment.Spec.Template.Spec.Containers[0].Command = command
-	}
-
-	// Update the deployment's command if one was provided.
-	if o.PodArgs != "" {
-		cmdargs := strings.Fields(o.PodArgs)
-		deployment.Spec.Template.Spec.Containers[0].Args = cmdargs
-	}
-
-	// Add the NodeName if one was provided.
-	if o.NodeName != "" {
-		deployment.Spec.Template.Spec.NodeName = o.NodeName
-	}
-
-	// Mount the hosts's filesystem if exec-ing into a node.
-	if o.NodeExec {
-		// create the volume.
-		deployment.Spec.Template.Spec.Volumes = []corev1.Volume{
-			{
-				Name: "host-rootfs",
-				VolumeSource: corev1.VolumeSource{
-					HostPath: &corev1.HostPathVolumeSource{
-						Path: "/",
-					},
-				},
-			},
-		}
-
-		// attach it to the container
-		deployment.Spec.Template.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{
-			{
-				Name:      "host-rootfs",
-				MountPath: "/host",
-			},
-		}
-	}
-
-	var err error
-
+	// If dry-run is enabled, print the manifest and return
 	if o.DryRun {
-		err = utils.PrintManifestYAML(deployment)
-		if err != nil {
-			return fmt.Errorf("deployment \"%s/%s\" manifest generation failed: %v", o.Namespace, o.Name, err)
+		if err := utils.PrintManifestYAML(deployment); err != nil {
+			return false, fmt.Errorf("deployment \"%s/%s\" manifest generation failed: %v", o.Namespace, o.Name, err)
 		}
-	} else {
-		_, err = k8sClientSet.AppsV1().Deployments(o.Namespace).Create(ctx, deployment, metav1.CreateOptions{})
+		return false, nil
 	}
 
-	if err != nil {
-		if statusError, isStatus := err.(*errors.StatusError); isStatus && statusError.Status().Reason == metav1.StatusReasonAlreadyExists {
-			return fmt.Errorf("deployment \"%s/%s\" already exists", o.Namespace, o.Name)
-		} else if err != nil {
-			return fmt.Errorf("deployment \"%s/%s\" was not created: %w", o.Namespace, o.Name, err)
-		}
-
-	} else {
-		log.Infof("deployment \"%s/%s\" created\n", o.Namespace, o.Name)
+	_, err := k8sClientSet.AppsV1().Deployments(o.Namespace).Create(ctx, deployment, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		// Leave existing resources alone so that create can be re-run.
+		logging.Resource(ctx, o.Namespace, "deployment", o.FullName, types.ActionExisted).
+			Infof("deployment \"%s/%s\" already exists; skipping", o.Namespace, o.Name)
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("deployment \"%s/%s\" was not created: %w", o.Namespace, o.Name, err)
 	}
 
-	return nil
+	logging.Resource(ctx, o.Namespace, "deployment", o.FullName, types.ActionCreated).
+		Infof("deployment \"%s/%s\" created", o.Namespace, o.Name)
+
+	return true, nil
 }
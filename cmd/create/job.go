@@ -0,0 +1,214 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package create
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/glitchcrab/sonar/internal/config"
+	"github.com/glitchcrab/sonar/internal/logging"
+	"github.com/glitchcrab/sonar/internal/types"
+	"github.com/glitchcrab/sonar/internal/utils"
+	log "github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	backoffLimit int32 = 0
+
+	// jobPollInterval is how often the Job and its pod are checked.
+	jobPollInterval = 2 * time.Second
+)
+
+// Container waiting reasons which will not resolve without intervention.
+var fatalWaitingReasons = map[string]bool{
+	"CreateContainerConfigError": true,
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+}
+
+func createJob(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.CreateConfig) (bool, error) {
+	// Define the Job. It runs once: a failed script is reported rather
+	// than retried.
+	job := &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "batch/v1",
+			Kind:       "Job",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations:     o.Annotations,
+			Labels:          o.Labels,
+			Name:            o.FullName,
+			Namespace:       o.Namespace,
+			OwnerReferences: o.OwnerReferences,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template:     podTemplate(o, corev1.RestartPolicyNever),
+		},
+	}
+
+	// If dry-run is enabled, print the manifest and return
+	if o.DryRun {
+		if err := utils.PrintManifestYAML(job); err != nil {
+			return false, fmt.Errorf("job \"%s/%s\" manifest generation failed: %v", o.Namespace, o.Name, err)
+		}
+		return false, nil
+	}
+
+	_, err := k8sClientSet.BatchV1().Jobs(o.Namespace).Create(ctx, job, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		// Leave existing resources alone so that create can be re-run.
+		logging.Resource(ctx, o.Namespace, "job", o.FullName, types.ActionExisted).
+			Infof("job \"%s/%s\" already exists; skipping", o.Namespace, o.Name)
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("job \"%s/%s\" was not created: %w", o.Namespace, o.Name, err)
+	}
+
+	logging.Resource(ctx, o.Namespace, "job", o.FullName, types.ActionCreated).
+		Infof("job \"%s/%s\" created", o.Namespace, o.Name)
+
+	return true, nil
+}
+
+// waitForJob waits for the session's Job to run, streams the logs of its
+// pod to stdout (or stderr if stdout carries a result document) and
+// returns an error if the Job failed.
+func waitForJob(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.CreateConfig) error {
+	log.Infof("waiting for job \"%s/%s\" to start", o.Namespace, o.FullName)
+
+	pod, err := waitForJobPod(k8sClientSet, ctx, o)
+	if err != nil {
+		return err
+	}
+
+	// Follow the logs until the container exits.
+	stream, err := k8sClientSet.CoreV1().Pods(o.Namespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container: "sonar",
+		Follow:    true,
+	}).Stream(ctx)
+	if err != nil {
+		return fmt.Errorf("could not stream logs of pod \"%s/%s\": %w", o.Namespace, pod, err)
+	}
+	defer stream.Close()
+
+	var out io.Writer = os.Stdout
+	if o.Output != "" {
+		out = os.Stderr
+	}
+
+	if _, err := io.Copy(out, stream); err != nil {
+		return fmt.Errorf("could not stream logs of pod \"%s/%s\": %w", o.Namespace, pod, err)
+	}
+
+	// The log stream ends when the container exits, but the Job's status
+	// may take a moment to catch up.
+	var failure string
+	err = wait.PollUntilContextCancel(ctx, jobPollInterval, true, func(ctx context.Context) (bool, error) {
+		job, err := k8sClientSet.BatchV1().Jobs(o.Namespace).Get(ctx, o.FullName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+
+		for _, c := range job.Status.Conditions {
+			if c.Status != corev1.ConditionTrue {
+				continue
+			}
+			switch c.Type {
+			case batchv1.JobComplete:
+				return true, nil
+			case batchv1.JobFailed:
+				failure = c.Message
+				if failure == "" {
+					failure = c.Reason
+				}
+				return true, nil
+			}
+		}
+
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("job \"%s/%s\" did not finish: %w", o.Namespace, o.FullName, err)
+	}
+
+	if failure != "" {
+		return fmt.Errorf("job \"%s/%s\" failed: %s", o.Namespace, o.FullName, failure)
+	}
+
+	log.Infof("job \"%s/%s\" succeeded", o.Namespace, o.FullName)
+
+	return nil
+}
+
+// waitForJobPod waits for the Job's pod to start and returns its name.
+// An error is returned if the pod cannot start.
+func waitForJobPod(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.CreateConfig) (string, error) {
+	pod, err := WaitForPod(k8sClientSet, ctx, o.Namespace, fmt.Sprintf("job-name=%s", o.FullName))
+	if err != nil {
+		return "", fmt.Errorf("job \"%s/%s\" did not start: %w", o.Namespace, o.FullName, err)
+	}
+
+	return pod.Name, nil
+}
+
+// WaitForPod waits for a pod matching the label selector to leave the
+// Pending phase and returns it. An error is returned if the pod cannot
+// start.
+func WaitForPod(k8sClientSet *kubernetes.Clientset, ctx context.Context, namespace, selector string) (*corev1.Pod, error) {
+	listOpts := metav1.ListOptions{
+		LabelSelector: selector,
+	}
+
+	var started *corev1.Pod
+	err := wait.PollUntilContextCancel(ctx, jobPollInterval, true, func(ctx context.Context) (bool, error) {
+		pods, err := k8sClientSet.CoreV1().Pods(namespace).List(ctx, listOpts)
+		if err != nil {
+			return false, err
+		}
+
+		for _, pod := range pods.Items {
+			if pod.Status.Phase != corev1.PodPending {
+				started = &pod
+				return true, nil
+			}
+
+			for _, status := range pod.Status.ContainerStatuses {
+				if w := status.State.Waiting; w != nil && fatalWaitingReasons[w.Reason] {
+					return false, fmt.Errorf("pod \"%s/%s\" cannot start: %s: %s", pod.Namespace, pod.Name, w.Reason, w.Message)
+				}
+			}
+		}
+
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return started, nil
+}
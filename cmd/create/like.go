@@ -0,0 +1,172 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package create
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/glitchcrab/sonar/internal/config"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// serviceAccountTokenPath is where the API server token is mounted. Pods
+// have the token volume injected at admission, so it must not be copied.
+const serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// defaultServiceAccount is the ServiceAccount which pods run as if none is
+// set.
+const defaultServiceAccount = "default"
+
+// ResolveLike looks up the workload named by opts.Like and stores the parts
+// of its pod spec which the debug pod should share (see copyPodSpec). If
+// the workload runs as a ServiceAccount other than the namespace's default
+// one and none was provided, the debug pod borrows it.
+func ResolveLike(k8sClientSet *kubernetes.Clientset, ctx context.Context, opts *config.CreateConfig) error {
+	if opts.Like == "" {
+		return nil
+	}
+
+	spec, err := getPodSpec(k8sClientSet, ctx, opts.Namespace, opts.Like)
+	if err != nil {
+		return err
+	}
+
+	opts.LikeSpec = copyPodSpec(spec)
+
+	if opts.ServiceAccount == "" && opts.LikeSpec.ServiceAccountName != "" {
+		opts.ServiceAccount = opts.LikeSpec.ServiceAccountName
+	}
+
+	logCopied(opts.Like, opts.LikeSpec)
+
+	return nil
+}
+
+// getPodSpec returns the pod spec of a pod, deployment or statefulset in
+// kind/name form.
+func getPodSpec(k8sClientSet *kubernetes.Clientset, ctx context.Context, namespace, like string) (*corev1.PodSpec, error) {
+	kind, name, ok := strings.Cut(like, "/")
+	if !ok || name == "" {
+		return nil, fmt.Errorf("invalid --like %q: expected pod/<name>, deployment/<name> or statefulset/<name>", like)
+	}
+
+	switch strings.ToLower(kind) {
+	case "pod", "pods", "po":
+		pod, err := k8sClientSet.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("pod \"%s/%s\" could not be retrieved: %w", namespace, name, err)
+		}
+		return &pod.Spec, nil
+	case "deployment", "deployments", "deploy":
+		deploy, err := k8sClientSet.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("deployment \"%s/%s\" could not be retrieved: %w", namespace, name, err)
+		}
+		return &deploy.Spec.Template.Spec, nil
+	case "statefulset", "statefulsets", "sts":
+		sts, err := k8sClientSet.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("statefulset \"%s/%s\" could not be retrieved: %w", namespace, name, err)
+		}
+		return &sts.Spec.Template.Spec, nil
+	default:
+		return nil, fmt.Errorf("invalid --like %q: unsupported kind %q (expected pod, deployment or statefulset)", like, kind)
+	}
+}
+
+// copyPodSpec returns a pod spec containing only the fields which are
+// shared with the debug pod: the first container's env, envFrom and volume
+// mounts, and the pod's volumes, ServiceAccount, node selector and
+// tolerations. Probes, lifecycle hooks, resources and everything else are
+// left behind.
+func copyPodSpec(spec *corev1.PodSpec) *corev1.PodSpec {
+	copied := &corev1.PodSpec{
+		NodeSelector: spec.NodeSelector,
+		Tolerations:  spec.Tolerations,
+	}
+
+	// Every pod runs as a ServiceAccount, so only a dedicated one is worth
+	// borrowing; the session's own ServiceAccount replaces the default.
+	if spec.ServiceAccountName != defaultServiceAccount {
+		copied.ServiceAccountName = spec.ServiceAccountName
+	}
+
+	// Skip the injected ServiceAccount token volume, as the debug pod gets
+	// its own.
+	tokenVolumes := make(map[string]bool)
+	var container corev1.Container
+	if len(spec.Containers) > 0 {
+		source := spec.Containers[0]
+		container.Env = source.Env
+		container.EnvFrom = source.EnvFrom
+		for _, m := range source.VolumeMounts {
+			if m.MountPath == serviceAccountTokenPath {
+				tokenVolumes[m.Name] = true
+				continue
+			}
+			container.VolumeMounts = append(container.VolumeMounts, m)
+		}
+	}
+	copied.Containers = []corev1.Container{container}
+
+	// Only copy the volumes which the container mounts.
+	mounted := make(map[string]bool)
+	for _, m := range container.VolumeMounts {
+		mounted[m.Name] = true
+	}
+	for _, v := range spec.Volumes {
+		if mounted[v.Name] && !tokenVolumes[v.Name] {
+			copied.Volumes = append(copied.Volumes, v)
+		}
+	}
+
+	return copied
+}
+
+// logCopied reports what was copied from the workload.
+func logCopied(like string, spec *corev1.PodSpec) {
+	c := spec.Containers[0]
+
+	log.Infof("copying from %s:", like)
+	for _, e := range c.Env {
+		log.Infof("  env: %s", e.Name)
+	}
+	for _, e := range c.EnvFrom {
+		switch {
+		case e.ConfigMapRef != nil:
+			log.Infof("  envFrom: configmap/%s", e.ConfigMapRef.Name)
+		case e.SecretRef != nil:
+			log.Infof("  envFrom: secret/%s", e.SecretRef.Name)
+		}
+	}
+	for _, m := range c.VolumeMounts {
+		log.Infof("  volume: %s at %s", m.Name, m.MountPath)
+	}
+	if spec.ServiceAccountName != "" {
+		log.Infof("  serviceaccount: %s", spec.ServiceAccountName)
+	}
+	for k, v := range spec.NodeSelector {
+		log.Infof("  nodeSelector: %s=%s", k, v)
+	}
+	for _, t := range spec.Tolerations {
+		log.Infof("  toleration: %s %s %s:%s", t.Key, t.Operator, t.Value, t.Effect)
+	}
+}
@@ -0,0 +1,60 @@
+package create
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestCopyPodSpec(t *testing.T) {
+	testCases := []struct {
+		name           string
+		serviceAccount string
+		expected       string
+	}{
+		{
+			name:           "test dedicated serviceaccount",
+			serviceAccount: "my-app",
+			expected:       "my-app",
+		},
+		{
+			name:           "test default serviceaccount",
+			serviceAccount: "default",
+		},
+		{
+			name: "test no serviceaccount",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			spec := &corev1.PodSpec{
+				ServiceAccountName: testCase.serviceAccount,
+				Containers: []corev1.Container{
+					{
+						Name: "app",
+						VolumeMounts: []corev1.VolumeMount{
+							{Name: "config", MountPath: "/etc/app"},
+							{Name: "token", MountPath: serviceAccountTokenPath},
+						},
+					},
+				},
+				Volumes: []corev1.Volume{
+					{Name: "config"},
+					{Name: "token"},
+					{Name: "unused"},
+				},
+			}
+
+			copied := copyPodSpec(spec)
+
+			if diff := deep.Equal(copied.ServiceAccountName, testCase.expected); diff != nil {
+				t.Error(diff)
+			}
+			if diff := deep.Equal(copied.Volumes, []corev1.Volume{{Name: "config"}}); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
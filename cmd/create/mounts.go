@@ -0,0 +1,190 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package create
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/glitchcrab/sonar/internal/config"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// scriptMode makes the session's scripts executable.
+var scriptMode int32 = 0o555
+
+// mountVolumes returns the volumes and volume mounts for o.Mounts and
+// o.Scripts.
+func mountVolumes(o config.CreateConfig) ([]corev1.Volume, []corev1.VolumeMount) {
+	var volumes []corev1.Volume
+	var mounts []corev1.VolumeMount
+	for i, m := range o.Mounts {
+		name := fmt.Sprintf("%s-%d", m.Kind, i)
+
+		volume := corev1.Volume{Name: name}
+		switch m.Kind {
+		case config.MountConfigMap:
+			volume.ConfigMap = &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: m.Source},
+			}
+		case config.MountSecret:
+			volume.Secret = &corev1.SecretVolumeSource{
+				SecretName: m.Source,
+			}
+		case config.MountPVC:
+			volume.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: m.Source,
+				ReadOnly:  m.ReadOnly,
+			}
+		case config.MountEmptyDir:
+			volume.EmptyDir = &corev1.EmptyDirVolumeSource{}
+		}
+
+		volumes = append(volumes, volume)
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      name,
+			MountPath: m.Path,
+			ReadOnly:  m.ReadOnly,
+		})
+	}
+
+	// Mount the session's scripts as executables.
+	if len(o.Scripts) > 0 {
+		volumes = append(volumes, corev1.Volume{
+			Name: "sonar-scripts",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: o.ScriptsConfigMapName()},
+					DefaultMode:          &scriptMode,
+				},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      "sonar-scripts",
+			MountPath: config.ScriptsPath,
+			ReadOnly:  true,
+		})
+	}
+
+	return volumes, mounts
+}
+
+// volumeConflicts returns an error for every volume name or mount path which
+// is used more than once by the debug pod, as the API server would reject
+// the pod only after the rest of the session had been created. Volumes
+// copied from another workload (--like) are checked against the session's
+// own mounts, scripts and node-exec's host filesystem.
+func volumeConflicts(o config.CreateConfig) []error {
+	var volumes []corev1.Volume
+	var mounts []corev1.VolumeMount
+	if o.NodeExec {
+		volumes = append(volumes, corev1.Volume{Name: hostVolumeName})
+		mounts = append(mounts, corev1.VolumeMount{Name: hostVolumeName, MountPath: hostMountPath})
+	}
+	if o.LikeSpec != nil {
+		volumes = append(volumes, o.LikeSpec.Volumes...)
+		mounts = append(mounts, o.LikeSpec.Containers[0].VolumeMounts...)
+	}
+	ownVolumes, ownMounts := mountVolumes(o)
+	volumes = append(volumes, ownVolumes...)
+	mounts = append(mounts, ownMounts...)
+
+	var errs []error
+	names := make(map[string]bool)
+	for _, v := range volumes {
+		if names[v.Name] {
+			errs = append(errs, fmt.Errorf("volume %q is defined more than once", v.Name))
+		}
+		names[v.Name] = true
+	}
+
+	paths := make(map[string]string)
+	for _, m := range mounts {
+		path := strings.TrimSuffix(m.MountPath, "/")
+		if name, ok := paths[path]; ok {
+			errs = append(errs, fmt.Errorf("mount path %q is used by volumes %q and %q", m.MountPath, name, m.Name))
+			continue
+		}
+		paths[path] = m.Name
+	}
+
+	return errs
+}
+
+// checkMounts makes sure that the source of every mount exists, and warns
+// if a ReadWriteOnce PVC is already attached to a pod on another node, as
+// the debug pod would then be unable to start.
+func checkMounts(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.CreateConfig) error {
+	for _, m := range o.Mounts {
+		var err error
+		switch m.Kind {
+		case config.MountConfigMap:
+			_, err = k8sClientSet.CoreV1().ConfigMaps(o.Namespace).Get(ctx, m.Source, metav1.GetOptions{})
+		case config.MountSecret:
+			_, err = k8sClientSet.CoreV1().Secrets(o.Namespace).Get(ctx, m.Source, metav1.GetOptions{})
+		case config.MountPVC:
+			err = checkClaim(k8sClientSet, ctx, o, m.Source)
+		}
+		if err != nil {
+			return fmt.Errorf("%s \"%s/%s\" cannot be mounted: %w", m.Kind, o.Namespace, m.Source, err)
+		}
+	}
+
+	return nil
+}
+
+// checkClaim makes sure that a PVC exists and warns if it can only be
+// attached to a single node which the debug pod may not run on.
+func checkClaim(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.CreateConfig, claim string) error {
+	pvc, err := k8sClientSet.CoreV1().PersistentVolumeClaims(o.Namespace).Get(ctx, claim, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(pvc.Spec.AccessModes, corev1.ReadWriteOnce) && !slices.Contains(pvc.Spec.AccessModes, corev1.ReadWriteOncePod) {
+		return nil
+	}
+
+	pods, err := k8sClientSet.CoreV1().Pods(o.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		for _, v := range pod.Spec.Volumes {
+			if v.PersistentVolumeClaim == nil || v.PersistentVolumeClaim.ClaimName != claim {
+				continue
+			}
+
+			if slices.Contains(pvc.Spec.AccessModes, corev1.ReadWriteOncePod) {
+				log.Warnf("pvc \"%s/%s\" is ReadWriteOncePod and already used by pod %q; the debug pod will not start", o.Namespace, claim, pod.Name)
+			} else if pod.Spec.NodeName != o.NodeName {
+				log.Warnf("pvc \"%s/%s\" is ReadWriteOnce and already attached to node %q by pod %q; use --node-name %s to run the debug pod alongside it", o.Namespace, claim, pod.Spec.NodeName, pod.Name, pod.Spec.NodeName)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package create
+
+import (
+	"testing"
+
+	"github.com/glitchcrab/sonar/internal/config"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestVolumeConflicts(t *testing.T) {
+	likeSpec := func(name, path string) *corev1.PodSpec {
+		return &corev1.PodSpec{
+			Containers: []corev1.Container{
+				{VolumeMounts: []corev1.VolumeMount{{Name: name, MountPath: path}}},
+			},
+			Volumes: []corev1.Volume{{Name: name}},
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		config config.CreateConfig
+		errors int
+	}{
+		{
+			name: "test no conflicts",
+			config: config.CreateConfig{
+				LikeSpec: likeSpec("config", "/etc/app"),
+				Mounts:   []config.Mount{{Kind: config.MountEmptyDir, Path: "/scratch"}},
+				NodeExec: true,
+				Scripts:  map[string]string{"check.sh": "#!/bin/sh"},
+			},
+		},
+		{
+			name: "test mount path used by --like and --mount",
+			config: config.CreateConfig{
+				LikeSpec: likeSpec("config", "/etc/app"),
+				Mounts:   []config.Mount{{Kind: config.MountConfigMap, Source: "other", Path: "/etc/app/"}},
+			},
+			errors: 1,
+		},
+		{
+			name: "test mount path used by --like and node-exec",
+			config: config.CreateConfig{
+				LikeSpec: likeSpec("host", hostMountPath),
+				NodeExec: true,
+			},
+			errors: 1,
+		},
+		{
+			name: "test volume name used by --like and --script",
+			config: config.CreateConfig{
+				LikeSpec: likeSpec("sonar-scripts", "/opt/scripts"),
+				Scripts:  map[string]string{"check.sh": "#!/bin/sh"},
+			},
+			errors: 1,
+		},
+		{
+			name: "test volume name and mount path used by --like and --mount",
+			config: config.CreateConfig{
+				LikeSpec: likeSpec("emptydir-0", "/scratch"),
+				Mounts:   []config.Mount{{Kind: config.MountEmptyDir, Path: "/scratch"}},
+			},
+			errors: 2,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			errs := volumeConflicts(testCase.config)
+			if len(errs) != testCase.errors {
+				t.Errorf("expected %d errors, got %d: %v", testCase.errors, len(errs), errs)
+			}
+		})
+	}
+}
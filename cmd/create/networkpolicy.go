@@ -20,15 +20,16 @@ import (
 	"fmt"
 
 	"github.com/glitchcrab/sonar/internal/config"
+	"github.com/glitchcrab/sonar/internal/logging"
+	"github.com/glitchcrab/sonar/internal/types"
 	"github.com/glitchcrab/sonar/internal/utils"
-	log "github.com/sirupsen/logrus"
 	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func createNetworkPolicy(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.CreateConfig) error {
+func createNetworkPolicy(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.CreateConfig) (bool, error) {
 	// Define the NetworkPolicy
 	np := &networkingv1.NetworkPolicy{
 		TypeMeta: metav1.TypeMeta{
@@ -36,9 +37,11 @@ func createNetworkPolicy(k8sClientSet *kubernetes.Clientset, ctx context.Context
 			Kind:       "NetworkPolicy",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Labels:    o.Labels,
-			Name:      o.FullName,
-			Namespace: o.Namespace,
+			Annotations:     o.Annotations,
+			Labels:          o.Labels,
+			Name:            o.FullName,
+			Namespace:       o.Namespace,
+			OwnerReferences: o.OwnerReferences,
 		},
 		Spec: networkingv1.NetworkPolicySpec{
 			PodSelector: metav1.LabelSelector{
@@ -57,26 +60,26 @@ func createNetworkPolicy(k8sClientSet *kubernetes.Clientset, ctx context.Context
 		},
 	}
 
-	var err error
-
+	// If dry-run is enabled, print the manifest and return
 	if o.DryRun {
-		err = utils.PrintManifestYAML(np)
-		if err != nil {
-			return fmt.Errorf("networkpolicy \"%s\" manifest generation failed: %v", o.Name, err)
+		if err := utils.PrintManifestYAML(np); err != nil {
+			return false, fmt.Errorf("networkpolicy \"%s/%s\" manifest generation failed: %v", o.Namespace, o.Name, err)
 		}
-	} else {
-		_, err = k8sClientSet.NetworkingV1().NetworkPolicies(o.Namespace).Create(ctx, np, metav1.CreateOptions{})
+		return false, nil
 	}
 
-	if err != nil {
-		if statusError, isStatus := err.(*errors.StatusError); isStatus && statusError.Status().Reason == metav1.StatusReasonAlreadyExists {
-			return fmt.Errorf("networkpolicy \"%s\" already exists", o.Name)
-		} else if err != nil {
-			return fmt.Errorf("networkpolicy \"%s\" was not created: %w", o.Name, err)
-		}
-	} else {
-		log.Infof("networkpolicy \"%s/%s\" created\n", o.Namespace, o.Name)
+	_, err := k8sClientSet.NetworkingV1().NetworkPolicies(o.Namespace).Create(ctx, np, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		// Leave existing resources alone so that create can be re-run.
+		logging.Resource(ctx, o.Namespace, "networkpolicy", o.FullName, types.ActionExisted).
+			Infof("networkpolicy \"%s/%s\" already exists; skipping", o.Namespace, o.Name)
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("networkpolicy \"%s/%s\" was not created: %w", o.Namespace, o.Name, err)
 	}
 
-	return nil
+	logging.Resource(ctx, o.Namespace, "networkpolicy", o.FullName, types.ActionCreated).
+		Infof("networkpolicy \"%s/%s\" created", o.Namespace, o.Name)
+
+	return true, nil
 }
@@ -0,0 +1,271 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package create
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/glitchcrab/sonar/internal/config"
+	"github.com/glitchcrab/sonar/internal/logging"
+	"github.com/glitchcrab/sonar/internal/types"
+	"github.com/glitchcrab/sonar/internal/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// The host's filesystem is mounted at hostMountPath when exec-ing into a
+// node.
+const (
+	hostVolumeName = "host-rootfs"
+	hostMountPath  = "/host"
+)
+
+var (
+	automountToken = false
+	hostIPC        = false
+	hostNet        = false
+	hostPID        = false
+	sysctls        = []corev1.Sysctl{}
+)
+
+// podTemplate returns the template of the pod which runs the debug
+// container.
+func podTemplate(o config.CreateConfig, restartPolicy corev1.RestartPolicy) corev1.PodTemplateSpec {
+	// Create container in the host namespaces if node-exec is set.
+	if o.NodeExec {
+		hostIPC = true
+		hostNet = true
+		hostPID = true
+	}
+
+	securityContext := &corev1.SecurityContext{
+		Capabilities: &corev1.Capabilities{
+			Add:  capabilities(o.Capabilities),
+			Drop: []corev1.Capability{"ALL"},
+		},
+		Privileged:               &o.Privileged,
+		RunAsUser:                &o.PodUser,
+		RunAsGroup:               &o.PodGroup,
+		RunAsNonRoot:             &o.NonRoot,
+		AllowPrivilegeEscalation: &o.PrivilegeEscalation,
+		SeccompProfile: &corev1.SeccompProfile{
+			Type: "RuntimeDefault",
+		},
+	}
+
+	// Add sysctl to allow unprivileged users to use ping.
+	if o.UnprivilegedPing {
+		pingGroupRange := corev1.Sysctl{
+			Name:  "net.ipv4.ping_group_range",
+			Value: "0 2147483647",
+		}
+		sysctls = append(sysctls, pingGroupRange)
+	}
+
+	podSecurityContext := &corev1.PodSecurityContext{
+		RunAsUser:    &o.PodUser,
+		RunAsGroup:   &o.PodGroup,
+		RunAsNonRoot: &o.NonRoot,
+		SeccompProfile: &corev1.SeccompProfile{
+			Type: "RuntimeDefault",
+		},
+		Sysctls: sysctls,
+	}
+
+	// Define the pod template
+	template := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: o.Annotations,
+			Labels:      o.Labels,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Image: o.Image,
+					Name:  "sonar",
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("2"),
+							corev1.ResourceMemory: resource.MustParse("250Mi"),
+						},
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("200m"),
+							corev1.ResourceMemory: resource.MustParse("50Mi"),
+						},
+					},
+					SecurityContext: securityContext,
+				},
+			},
+			HostIPC:            hostIPC,
+			HostNetwork:        hostNet,
+			HostPID:            hostPID,
+			RestartPolicy:      restartPolicy,
+			ServiceAccountName: o.ServiceAccountName(),
+			SecurityContext:    podSecurityContext,
+		},
+	}
+
+	// Update the container's command if one was provided. Lists from a
+	// session file are used as-is so that their items may contain spaces.
+	if len(o.Command) > 0 {
+		template.Spec.Containers[0].Command = o.Command
+	} else if o.PodCommand != "" {
+		command := strings.Fields(o.PodCommand)
+		template.Spec.Containers[0].Command = command
+	}
+
+	// Update the container's args if any were provided.
+	if len(o.Args) > 0 {
+		template.Spec.Containers[0].Args = o.Args
+	} else if o.PodArgs != "" {
+		cmdargs := strings.Fields(o.PodArgs)
+		template.Spec.Containers[0].Args = cmdargs
+	}
+
+	// Don't mount the ServiceAccount token if requested.
+	if o.NoToken {
+		template.Spec.AutomountServiceAccountToken = &automountToken
+	}
+
+	// Add the NodeName if one was provided.
+	if o.NodeName != "" {
+		template.Spec.NodeName = o.NodeName
+	}
+
+	// Mount the hosts's filesystem if exec-ing into a node.
+	if o.NodeExec {
+		// create the volume.
+		template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+			Name: hostVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: "/",
+				},
+			},
+		})
+
+		// attach it to the container
+		template.Spec.Containers[0].VolumeMounts = append(template.Spec.Containers[0].VolumeMounts, corev1.VolumeMount{
+			Name:      hostVolumeName,
+			MountPath: hostMountPath,
+		})
+	}
+
+	// Add the user-provided environment. Inputs have already been validated.
+	container := &template.Spec.Containers[0]
+	for _, e := range o.Env {
+		key, value, _ := config.ParseEnv(e)
+		container.Env = append(container.Env, corev1.EnvVar{Name: key, Value: value})
+	}
+	for _, e := range o.EnvFrom {
+		kind, name, _ := config.ParseEnvFrom(e)
+		if kind == "configmap" {
+			container.EnvFrom = append(container.EnvFrom, corev1.EnvFromSource{
+				ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: name}},
+			})
+		} else {
+			container.EnvFrom = append(container.EnvFrom, corev1.EnvFromSource{
+				SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: name}},
+			})
+		}
+	}
+	container.ImagePullPolicy = corev1.PullPolicy(o.ImagePullPolicy)
+
+	// Add any image pull secrets, including the session's copy.
+	for _, name := range o.ImagePullSecrets {
+		template.Spec.ImagePullSecrets = append(template.Spec.ImagePullSecrets, corev1.LocalObjectReference{Name: name})
+	}
+	if o.CopyPullSecret != "" {
+		template.Spec.ImagePullSecrets = append(template.Spec.ImagePullSecrets, corev1.LocalObjectReference{Name: o.CopiedPullSecretName()})
+	}
+
+	// Share the environment of the workload which the session is like.
+	if o.LikeSpec != nil {
+		podSpec := &template.Spec
+		container.Env = append(container.Env, o.LikeSpec.Containers[0].Env...)
+		container.EnvFrom = append(container.EnvFrom, o.LikeSpec.Containers[0].EnvFrom...)
+		container.VolumeMounts = append(container.VolumeMounts, o.LikeSpec.Containers[0].VolumeMounts...)
+		podSpec.Volumes = append(podSpec.Volumes, o.LikeSpec.Volumes...)
+		podSpec.NodeSelector = o.LikeSpec.NodeSelector
+		podSpec.Tolerations = o.LikeSpec.Tolerations
+	}
+
+	// Add any user-provided mounts.
+	volumes, volumeMounts := mountVolumes(o)
+	template.Spec.Volumes = append(template.Spec.Volumes, volumes...)
+	template.Spec.Containers[0].VolumeMounts = append(template.Spec.Containers[0].VolumeMounts, volumeMounts...)
+
+	return template
+}
+
+func createPod(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.CreateConfig) (bool, error) {
+	// A bare pod is never restarted, as it can't be rescheduled either.
+	template := podTemplate(o, corev1.RestartPolicyNever)
+
+	// Define the Pod
+	pod := &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Pod",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations:     o.Annotations,
+			Labels:          o.Labels,
+			Name:            o.FullName,
+			Namespace:       o.Namespace,
+			OwnerReferences: o.OwnerReferences,
+		},
+		Spec: template.Spec,
+	}
+
+	// If dry-run is enabled, print the manifest and return
+	if o.DryRun {
+		if err := utils.PrintManifestYAML(pod); err != nil {
+			return false, fmt.Errorf("pod \"%s/%s\" manifest generation failed: %v", o.Namespace, o.Name, err)
+		}
+		return false, nil
+	}
+
+	_, err := k8sClientSet.CoreV1().Pods(o.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		// Leave existing resources alone so that create can be re-run.
+		logging.Resource(ctx, o.Namespace, "pod", o.FullName, types.ActionExisted).
+			Infof("pod \"%s/%s\" already exists; skipping", o.Namespace, o.Name)
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("pod \"%s/%s\" was not created: %w", o.Namespace, o.Name, err)
+	}
+
+	logging.Resource(ctx, o.Namespace, "pod", o.FullName, types.ActionCreated).
+		Infof("pod \"%s/%s\" created", o.Namespace, o.Name)
+
+	return true, nil
+}
+
+// capabilities converts capability names to Capabilities.
+func capabilities(names []string) []corev1.Capability {
+	var caps []corev1.Capability
+	for _, name := range names {
+		caps = append(caps, corev1.Capability(name))
+	}
+
+	return caps
+}
@@ -0,0 +1,179 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package create
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/glitchcrab/sonar/internal/config"
+	"github.com/glitchcrab/sonar/internal/logging"
+	"github.com/glitchcrab/sonar/internal/types"
+	"github.com/glitchcrab/sonar/internal/utils"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// readOnlyVerbs are granted on every resource of a generated role.
+var readOnlyVerbs = []string{"get", "list", "watch"}
+
+// createRole creates a read-only Role for the resources listed in
+// o.ReadOnlyResources.
+func createRole(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.CreateConfig) (bool, error) {
+	// Define the Role
+	role := &rbacv1.Role{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "rbac.authorization.k8s.io/v1",
+			Kind:       "Role",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations:     o.Annotations,
+			Labels:          o.Labels,
+			Name:            o.FullName,
+			Namespace:       o.Namespace,
+			OwnerReferences: o.OwnerReferences,
+		},
+		Rules: readOnlyRules(o.ReadOnlyResources),
+	}
+
+	// If dry-run is enabled, print the manifest and return
+	if o.DryRun {
+		if err := utils.PrintManifestYAML(role); err != nil {
+			return false, fmt.Errorf("role \"%s/%s\" manifest generation failed: %v", o.Namespace, o.Name, err)
+		}
+		return false, nil
+	}
+
+	_, err := k8sClientSet.RbacV1().Roles(o.Namespace).Create(ctx, role, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		// Leave existing resources alone so that create can be re-run.
+		logging.Resource(ctx, o.Namespace, "role", o.FullName, types.ActionExisted).
+			Infof("role \"%s/%s\" already exists; skipping", o.Namespace, o.Name)
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("role \"%s/%s\" was not created: %w", o.Namespace, o.Name, err)
+	}
+
+	logging.Resource(ctx, o.Namespace, "role", o.FullName, types.ActionCreated).
+		Infof("role \"%s/%s\" created", o.Namespace, o.Name)
+
+	return true, nil
+}
+
+// createRoleBinding binds the session's ServiceAccount to the requested
+// Role or ClusterRole within the session's namespace.
+func createRoleBinding(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.CreateConfig) (bool, error) {
+	roleRef := rbacv1.RoleRef{
+		APIGroup: "rbac.authorization.k8s.io",
+	}
+
+	switch {
+	case o.ClusterRole != "":
+		roleRef.Kind = "ClusterRole"
+		roleRef.Name = o.ClusterRole
+	case o.Role != "":
+		roleRef.Kind = "Role"
+		roleRef.Name = o.Role
+	default:
+		// Bind the generated read-only role.
+		roleRef.Kind = "Role"
+		roleRef.Name = o.FullName
+	}
+
+	// Define the RoleBinding
+	binding := &rbacv1.RoleBinding{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "rbac.authorization.k8s.io/v1",
+			Kind:       "RoleBinding",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations:     o.Annotations,
+			Labels:          o.Labels,
+			Name:            o.FullName,
+			Namespace:       o.Namespace,
+			OwnerReferences: o.OwnerReferences,
+		},
+		RoleRef: roleRef,
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      "ServiceAccount",
+				Name:      o.FullName,
+				Namespace: o.Namespace,
+			},
+		},
+	}
+
+	// If dry-run is enabled, print the manifest and return
+	if o.DryRun {
+		if err := utils.PrintManifestYAML(binding); err != nil {
+			return false, fmt.Errorf("rolebinding \"%s/%s\" manifest generation failed: %v", o.Namespace, o.Name, err)
+		}
+		return false, nil
+	}
+
+	_, err := k8sClientSet.RbacV1().RoleBindings(o.Namespace).Create(ctx, binding, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		// Leave existing resources alone so that create can be re-run.
+		logging.Resource(ctx, o.Namespace, "rolebinding", o.FullName, types.ActionExisted).
+			Infof("rolebinding \"%s/%s\" already exists; skipping", o.Namespace, o.Name)
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("rolebinding \"%s/%s\" was not created: %w", o.Namespace, o.Name, err)
+	}
+
+	logging.Resource(ctx, o.Namespace, "rolebinding", o.FullName, types.ActionCreated).
+		Infof("rolebinding \"%s/%s\" created (%s %q)", o.Namespace, o.Name, strings.ToLower(roleRef.Kind), roleRef.Name)
+
+	return true, nil
+}
+
+// checkRole makes sure that an existing Role or ClusterRole which is to be
+// bound is actually there, as the binding would otherwise silently grant
+// nothing.
+func checkRole(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.CreateConfig) error {
+	switch {
+	case o.ClusterRole != "":
+		if _, err := k8sClientSet.RbacV1().ClusterRoles().Get(ctx, o.ClusterRole, metav1.GetOptions{}); err != nil {
+			return fmt.Errorf("clusterrole %q could not be found: %w", o.ClusterRole, err)
+		}
+	case o.Role != "":
+		if _, err := k8sClientSet.RbacV1().Roles(o.Namespace).Get(ctx, o.Role, metav1.GetOptions{}); err != nil {
+			return fmt.Errorf("role \"%s/%s\" could not be found: %w", o.Namespace, o.Role, err)
+		}
+	}
+
+	return nil
+}
+
+// readOnlyRules returns a rule granting read-only access to each resource.
+// Resources are provided as "resource" for the core API group, or
+// "resource.group" (e.g. "deployments.apps") for any other group.
+func readOnlyRules(resources []string) []rbacv1.PolicyRule {
+	var rules []rbacv1.PolicyRule
+	for _, r := range resources {
+		resource, group, _ := strings.Cut(r, ".")
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{group},
+			Resources: []string{resource},
+			Verbs:     readOnlyVerbs,
+		})
+	}
+
+	return rules
+}
@@ -0,0 +1,95 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package create
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/glitchcrab/sonar/cmd/destroy"
+	"github.com/glitchcrab/sonar/internal/config"
+	"github.com/glitchcrab/sonar/internal/logging"
+	"github.com/glitchcrab/sonar/internal/types"
+	"github.com/glitchcrab/sonar/internal/utils"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+)
+
+// rollbackTimeout bounds how long rolling back a partially created session
+// may take. The command's own context has already been cancelled by then.
+const rollbackTimeout = 30 * time.Second
+
+// handleFailure rolls back the resources which were created before a
+// failure. If the context was cancelled the user is asked first, as they
+// may have interrupted Sonar deliberately. The outcome of deleting each
+// resource is returned.
+func handleFailure(k8sClientSet *kubernetes.Clientset, ctx context.Context, opts config.CreateConfig, created []types.SessionResource) ([]types.ResourceResult, error) {
+	if len(created) == 0 {
+		return nil, nil
+	}
+
+	if opts.KeepOnFailure {
+		log.Warnf("keep-on-failure was set, not rolling back %d created resources", len(created))
+		for _, r := range created {
+			logging.Resource(ctx, r.Namespace, r.Kind, r.Name, types.ActionCreated).Warnf("kept %s", r)
+		}
+		return nil, nil
+	}
+
+	if ctx.Err() != nil {
+		return offerRollback(k8sClientSet, ctx, created)
+	}
+
+	log.Warnf("creation failed, rolling back %d created resources", len(created))
+
+	return rollback(k8sClientSet, created)
+}
+
+// offerRollback asks the user whether the resources which were created
+// before the command was interrupted should be deleted.
+func offerRollback(k8sClientSet *kubernetes.Clientset, ctx context.Context, created []types.SessionResource) ([]types.ResourceResult, error) {
+	log.Warnf("creation was interrupted after %d resources were created", len(created))
+	for _, r := range created {
+		logging.Resource(ctx, r.Namespace, r.Kind, r.Name, types.ActionCreated).Warnf("created %s", r)
+	}
+
+	ok, err := utils.ConfirmationPrompt(fmt.Sprintf("%d partially created resources of session", len(created)), created[0].Name)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	return rollback(k8sClientSet, created)
+}
+
+// rollback deletes the created resources, most recently created first.
+func rollback(k8sClientSet *kubernetes.Clientset, created []types.SessionResource) ([]types.ResourceResult, error) {
+	plan := slices.Clone(created)
+	slices.Reverse(plan)
+
+	ctx, cancel := context.WithTimeout(context.Background(), rollbackTimeout)
+	defer cancel()
+
+	results, err := destroy.Delete(k8sClientSet, ctx, plan)
+	if err != nil {
+		return results, fmt.Errorf("rollback failed: %w", err)
+	}
+
+	log.Infof("rolled back %d resources", len(plan))
+
+	return results, nil
+}
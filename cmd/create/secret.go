@@ -0,0 +1,94 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package create
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/glitchcrab/sonar/internal/config"
+	"github.com/glitchcrab/sonar/internal/logging"
+	"github.com/glitchcrab/sonar/internal/types"
+	"github.com/glitchcrab/sonar/internal/utils"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// createPullSecret copies the image pull secret named by o.CopyPullSecret
+// into the session's namespace. The copy is owned by the session, so it is
+// deleted along with it.
+func createPullSecret(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.CreateConfig) (bool, error) {
+	sourceNamespace, sourceName, err := config.ParseSecretRef(o.CopyPullSecret)
+	if err != nil {
+		return false, err
+	}
+
+	// Define the Secret
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations:     o.Annotations,
+			Labels:          o.Labels,
+			Name:            o.CopiedPullSecretName(),
+			Namespace:       o.Namespace,
+			OwnerReferences: o.OwnerReferences,
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+	}
+
+	// If dry-run is enabled, print the manifest (without any data) and
+	// return
+	if o.DryRun {
+		log.Infof("secret \"%s/%s\" will be copied from \"%s/%s\"", o.Namespace, o.CopiedPullSecretName(), sourceNamespace, sourceName)
+		if err := utils.PrintManifestYAML(secret); err != nil {
+			return false, fmt.Errorf("secret \"%s/%s\" manifest generation failed: %v", o.Namespace, o.CopiedPullSecretName(), err)
+		}
+		return false, nil
+	}
+
+	source, err := k8sClientSet.CoreV1().Secrets(sourceNamespace).Get(ctx, sourceName, metav1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("secret \"%s/%s\" could not be retrieved: %w", sourceNamespace, sourceName, err)
+	}
+
+	if source.Type != corev1.SecretTypeDockerConfigJson && source.Type != corev1.SecretTypeDockercfg {
+		return false, fmt.Errorf("secret \"%s/%s\" is not an image pull secret (type %s)", sourceNamespace, sourceName, source.Type)
+	}
+
+	secret.Type = source.Type
+	secret.Data = source.Data
+
+	_, err = k8sClientSet.CoreV1().Secrets(o.Namespace).Create(ctx, secret, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		// Leave existing resources alone so that create can be re-run.
+		logging.Resource(ctx, o.Namespace, "secret", secret.Name, types.ActionExisted).
+			Infof("secret \"%s/%s\" already exists; skipping", o.Namespace, secret.Name)
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("secret \"%s/%s\" was not created: %w", o.Namespace, secret.Name, err)
+	}
+
+	logging.Resource(ctx, o.Namespace, "secret", secret.Name, types.ActionCreated).
+		Infof("secret \"%s/%s\" copied from \"%s/%s\"", o.Namespace, secret.Name, sourceNamespace, sourceName)
+
+	return true, nil
+}
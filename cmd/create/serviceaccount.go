@@ -20,15 +20,16 @@ import (
 	"fmt"
 
 	"github.com/glitchcrab/sonar/internal/config"
+	"github.com/glitchcrab/sonar/internal/logging"
+	"github.com/glitchcrab/sonar/internal/types"
 	"github.com/glitchcrab/sonar/internal/utils"
-	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func createServiceAccount(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.CreateConfig) error {
+func createServiceAccount(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.CreateConfig) (bool, error) {
 	// Define the ServiceAccount
 	sa := &corev1.ServiceAccount{
 		TypeMeta: metav1.TypeMeta{
@@ -36,33 +37,39 @@ func createServiceAccount(k8sClientSet *kubernetes.Clientset, ctx context.Contex
 			Kind:       "ServiceAccount",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Labels:    o.Labels,
-			Name:      o.FullName,
-			Namespace: o.Namespace,
+			Annotations:     o.Annotations,
+			Labels:          o.Labels,
+			Name:            o.FullName,
+			Namespace:       o.Namespace,
+			OwnerReferences: o.OwnerReferences,
 		},
 	}
 
-	var err error
+	// Stop the token from being mounted into pods if requested.
+	if o.NoToken {
+		sa.AutomountServiceAccountToken = &automountToken
+	}
 
 	// If dry-run is enabled, print the manifest and return
 	if o.DryRun {
-		err = utils.PrintManifestYAML(sa)
-		if err != nil {
-			return fmt.Errorf("serviceaccount \"%s/%s\" manifest generation failed: %v", o.Namespace, o.Name, err)
+		if err := utils.PrintManifestYAML(sa); err != nil {
+			return false, fmt.Errorf("serviceaccount \"%s/%s\" manifest generation failed: %v", o.Namespace, o.Name, err)
 		}
-	} else {
-		_, err = k8sClientSet.CoreV1().ServiceAccounts(o.Namespace).Create(ctx, sa, metav1.CreateOptions{})
+		return false, nil
 	}
 
-	if err != nil {
-		if statusError, isStatus := err.(*errors.StatusError); isStatus && statusError.Status().Reason == metav1.StatusReasonAlreadyExists {
-			return fmt.Errorf("serviceaccount \"%s/%s\" already exists", o.Namespace, o.Name)
-		} else if err != nil {
-			return fmt.Errorf("serviceaccount \"%s/%s\" was not created: %w", o.Namespace, o.Name, err)
-		}
-	} else {
-		log.Infof("serviceaccount \"%s/%s\" created\n", o.Namespace, o.Name)
+	_, err := k8sClientSet.CoreV1().ServiceAccounts(o.Namespace).Create(ctx, sa, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		// Leave existing resources alone so that create can be re-run.
+		logging.Resource(ctx, o.Namespace, "serviceaccount", o.FullName, types.ActionExisted).
+			Infof("serviceaccount \"%s/%s\" already exists; skipping", o.Namespace, o.Name)
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("serviceaccount \"%s/%s\" was not created: %w", o.Namespace, o.Name, err)
 	}
 
-	return nil
+	logging.Resource(ctx, o.Namespace, "serviceaccount", o.FullName, types.ActionCreated).
+		Infof("serviceaccount \"%s/%s\" created", o.Namespace, o.Name)
+
+	return true, nil
 }
@@ -3,29 +3,43 @@ package create
 import (
 	"errors"
 	"fmt"
-	"regexp"
+	"strings"
+	"time"
 
 	"github.com/glitchcrab/sonar/internal/config"
-	"github.com/spf13/cobra"
 )
 
-const (
-	imageRegex = "^[a-z0-9/.-]*[:][a-z0-9.-]*$"
-)
-
-func validateCreateConfig(command *cobra.Command, c *config.CreateConfig) error {
+// ValidateConfig validates a CreateConfig and sets any options which are
+// implied by other options.
+func ValidateConfig(c *config.CreateConfig) error {
 	var errs []error
 
-	if command.Flags().Lookup("image").Changed {
-		// Validate image to see if a tag has been provided; if not then
-		// use :latest. Does not validate full image name, just whether a
-		// tag was provided.
-		ok, _ := regexp.MatchString(imageRegex, image)
-		if !ok {
-			image = fmt.Sprintf("%s:latest", image)
+	// Add the 'latest' tag if neither a tag nor a digest was provided, and
+	// make sure that the image comes from an allowed registry.
+	if img, err := config.NormaliseImage(c.Image); err != nil {
+		errs = append(errs, fmt.Errorf("--image: %w", err))
+	} else {
+		c.Image = img
+
+		ok, err := config.ImageAllowed(c.Image, c.AllowedRegistries)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("--image: %w", err))
+		} else if !ok {
+			errs = append(errs, fmt.Errorf("--image: %q is not in an allowed registry (allowed: %s)", c.Image, strings.Join(c.AllowedRegistries, ", ")))
 		}
 	}
 
+	if kind, err := config.ParseKind(c.Kind); err != nil {
+		errs = append(errs, fmt.Errorf("--kind: %w", err))
+	} else {
+		c.Kind = kind
+	}
+
+	// Capabilities are named without the 'CAP_' prefix in a pod spec.
+	for i, name := range c.Capabilities {
+		c.Capabilities[i] = strings.TrimPrefix(strings.ToUpper(name), "CAP_")
+	}
+
 	// Set sane options if we're exec-ing into a node.
 	if c.NodeExec {
 		// Error out if node name was not provided.
@@ -38,6 +52,83 @@ func validateCreateConfig(command *cobra.Command, c *config.CreateConfig) error
 		c.Privileged = true
 	}
 
+	// A privileged container always allows privilege escalation, and the
+	// API server rejects a pod which says otherwise.
+	if c.Privileged {
+		c.PrivilegeEscalation = true
+	}
+
+	// Only a single role may be bound, and there's no point binding one if
+	// the token won't be mounted.
+	roles := 0
+	for _, set := range []bool{c.ClusterRole != "", c.Role != "", len(c.ReadOnlyResources) > 0} {
+		if set {
+			roles++
+		}
+	}
+	if roles > 1 {
+		errs = append(errs, fmt.Errorf("only one of --cluster-role, --role, --view and --read-only may be provided"))
+	}
+	if c.NoToken && c.BindsRole() {
+		errs = append(errs, fmt.Errorf("--no-token cannot be used when binding a role"))
+	}
+	for _, r := range c.ReadOnlyResources {
+		if resource, _, _ := strings.Cut(r, "."); resource == "" || strings.ContainsAny(r, " /") {
+			errs = append(errs, fmt.Errorf("--read-only: invalid resource %q (expected 'resource' or 'resource.group')", r))
+		}
+	}
+
+	// Sonar doesn't own a borrowed ServiceAccount, so it must not grant it
+	// any additional permissions.
+	if c.ServiceAccount != "" && c.BindsRole() {
+		errs = append(errs, fmt.Errorf("a borrowed ServiceAccount (--service-account or --like) cannot be used when binding a role"))
+	}
+
+	for _, e := range c.Env {
+		if _, _, err := config.ParseEnv(e); err != nil {
+			errs = append(errs, fmt.Errorf("--env: %w", err))
+		}
+	}
+	for _, e := range c.EnvFrom {
+		if _, _, err := config.ParseEnvFrom(e); err != nil {
+			errs = append(errs, fmt.Errorf("--env-from: %w", err))
+		}
+	}
+	if err := config.ValidateImagePullPolicy(c.ImagePullPolicy); err != nil {
+		errs = append(errs, fmt.Errorf("--image-pull-policy: %w", err))
+	}
+	if c.CopyPullSecret != "" {
+		if _, _, err := config.ParseSecretRef(c.CopyPullSecret); err != nil {
+			errs = append(errs, fmt.Errorf("--copy-pull-secret: %w", err))
+		}
+	}
+
+	// Make sure that no volume name or mount path is used twice.
+	errs = append(errs, volumeConflicts(*c)...)
+
+	// Label every resource with the session's ID so that destroy can find
+	// exactly the resources which belong to it.
+	if c.Labels == nil {
+		c.Labels = make(map[string]string)
+	}
+	c.Labels[config.LabelSession] = config.SessionID(c.Namespace, c.FullName)
+
+	if c.Annotations == nil {
+		c.Annotations = make(map[string]string)
+	}
+
+	// Flag sessions which run as a borrowed identity.
+	if c.ServiceAccount != "" {
+		c.Annotations[config.AnnotationBorrowedServiceAccount] = c.ServiceAccount
+	}
+
+	// Record when the session expires if a TTL was provided.
+	if c.TTL < 0 {
+		errs = append(errs, fmt.Errorf("--ttl must not be negative"))
+	} else if c.TTL > 0 {
+		c.Annotations[config.AnnotationExpiresAt] = time.Now().Add(c.TTL).UTC().Format(time.RFC3339)
+	}
+
 	// If there were any validation errors, return them as a single error.
 	if len(errs) > 0 {
 		return errors.Join(errs...)
@@ -0,0 +1,68 @@
+package create
+
+import (
+	"testing"
+
+	"github.com/glitchcrab/sonar/internal/config"
+)
+
+func TestValidateConfig(t *testing.T) {
+	testCases := []struct {
+		name                string
+		config              config.CreateConfig
+		privileged          bool
+		privilegeEscalation bool
+		wantErr             bool
+	}{
+		{
+			name:   "test unprivileged",
+			config: config.CreateConfig{},
+		},
+		{
+			name:                "test privilege escalation",
+			config:              config.CreateConfig{PrivilegeEscalation: true},
+			privilegeEscalation: true,
+		},
+		{
+			name:                "test privileged",
+			config:              config.CreateConfig{Privileged: true},
+			privileged:          true,
+			privilegeEscalation: true,
+		},
+		{
+			name:                "test node exec",
+			config:              config.CreateConfig{NodeExec: true, NodeName: "worker1"},
+			privileged:          true,
+			privilegeEscalation: true,
+		},
+		{
+			name:                "test node exec without node name",
+			config:              config.CreateConfig{NodeExec: true},
+			privileged:          true,
+			privilegeEscalation: true,
+			wantErr:             true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := testCase.config
+			c.Image = "busybox"
+			c.Kind = config.KindDeployment
+			c.Namespace = "default"
+			c.FullName = "sonar-debug"
+
+			err := ValidateConfig(&c)
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if c.Privileged != testCase.privileged {
+				t.Errorf("expected privileged %t, got %t", testCase.privileged, c.Privileged)
+			}
+			if c.PrivilegeEscalation != testCase.privilegeEscalation {
+				t.Errorf("expected privilege escalation %t, got %t", testCase.privilegeEscalation, c.PrivilegeEscalation)
+			}
+		})
+	}
+}
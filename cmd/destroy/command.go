@@ -16,23 +16,30 @@ limitations under the License.
 package destroy
 
 import (
-	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/glitchcrab/sonar/internal/app"
-	"github.com/glitchcrab/sonar/internal/config"
 	"github.com/glitchcrab/sonar/internal/k8sclient"
+	"github.com/glitchcrab/sonar/internal/report"
+	"github.com/glitchcrab/sonar/internal/session"
+	"github.com/glitchcrab/sonar/internal/types"
 	"github.com/glitchcrab/sonar/internal/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-	networkingv1 "k8s.io/api/networking/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var (
-	force bool
+	all       bool
+	filename  string
+	force     bool
+	olderThan time.Duration
+	output    string
+	selector  string
 )
 
 func NewCommand() *cobra.Command {
@@ -52,24 +59,82 @@ Global flags:
 
 Run "sonar help" in order to see flags which apply to all subcommands.
 
+Every resource created by Sonar is labelled with its session's ID.
+Delete finds every kind of resource with the selected sessions' labels,
+across all namespaces, and displays the full plan before asking for a
+single confirmation. Resources are deleted in dependency order, and a
+summary with one line per resource (kind, namespace, name, result and
+error) is printed at the end.
+
+Each session's anchor ConfigMap owns every other resource in the
+session, so deleting it cascades to the rest of the session.
+
+Unless --all or --name is provided, the user is prompted to select one
+or more of the matching sessions.
+
 Flags:
 
+--all (default: false)
+
+Deletes every session which matches the other filters without
+prompting for a selection.
+
+--filename/-f (default: none)
+
+Path to a session file (see "sonar apply"). The session's name and
+namespace are used to find the resources to delete.
+
 --force (default: false)
 
-Skips conformation prompt and deletes all resources created by Sonar.`,
+Skips conformation prompt and deletes all resources created by Sonar.
+Note that -f is the shorthand for --filename (as with "sonar apply")
+and no longer for --force.
+
+--older-than (default: none)
+
+Only matches sessions which were created longer ago than the provided
+duration (e.g. '12h').
+
+--output/-o (default: none)
+
+Prints a result document in the provided format ('json' or 'yaml')
+instead of the summary table. It lists each resource with the action
+taken on it (deleted, skipped or failed), any error and, when using
+multiple contexts, its cluster. The document's apiVersion is the schema
+version. The plan is written to stderr before confirming.
+
+--selector/-l (default: none)
+
+Only matches sessions whose workload (deployment, job or pod) matches
+the provided label selector (e.g. 'team=network').`,
 		Example: `
-"sonar delete" - prompts the user to select a Sonar deployment from a
-list of all matching deployments in a cluster.
+"sonar delete" - prompts the user to select one or more Sonar
+sessions from a list of all matching sessions in a cluster.
 
 "sonar delete --name test" - deletes all resources named 'test'.
 in namespace 'kube-system' named 'sonar-test'.
 
-NOTE: passing the --namespace flag will scope the search for deployments
+"sonar delete -f session.yaml" - deletes the session described in
+session.yaml.
+
+"sonar delete --all --older-than 24h --namespace workshop" - deletes
+every session in namespace 'workshop' created more than a day ago.
+
+"sonar delete --name test --context 'prod-*'" - deletes the session named
+'sonar-test' from every cluster whose context matches 'prod-*' and
+prints a summary for each cluster.
+
+NOTE: passing the --namespace flag will scope the search for sessions
 to a specific namespace.`,
 		RunE: runDeleteCommand,
 	}
 
-	command.Flags().BoolVarP(&force, "force", "f", false, "skip all confirmation prompts when deleting")
+	command.Flags().BoolVar(&all, "all", false, "delete all matching sessions without prompting for a selection")
+	command.Flags().StringVarP(&filename, "filename", "f", "", "path to a session file describing the session to delete")
+	command.Flags().BoolVar(&force, "force", false, "skip all confirmation prompts when deleting")
+	command.Flags().DurationVar(&olderThan, "older-than", 0, "only match sessions older than the provided duration")
+	command.Flags().StringVarP(&output, "output", "o", "", "print a result document (json|yaml)")
+	command.Flags().StringVarP(&selector, "selector", "l", "", "only match sessions matching the label selector")
 
 	return command
 }
@@ -81,161 +146,207 @@ func runDeleteCommand(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Create a Kubernetes clientset.
-	k8sClientSet, err := k8sclient.New(a.Globals.KubeContext, a.Globals.KubeConfig)
+	v, err := app.GetViper(cmd)
 	if err != nil {
 		return err
 	}
 
-	// Check if the user provided the --name flag, if so we skip the interactive lookup.
-	var skipInteractiveLookup bool
-	if a.Globals.Name != "" {
-		skipInteractiveLookup = true
-		log.Info("name flag was set, skipping interactive selection")
+	if err := report.ValidateFormat(output); err != nil {
+		return err
+	}
+
+	// Check if the user provided a name, if so we skip the interactive lookup.
+	nameProvided := v.IsSet("name")
+
+	// If a session file was provided, use its name and namespace.
+	if filename != "" {
+		s, err := session.Load(filename)
+		if err != nil {
+			return err
+		}
+
+		a.Globals, err = s.Globals(a.Globals)
+		if err != nil {
+			return err
+		}
+		nameProvided = true
 	}
 
-	/// Set whether we search all namespaces or scope to a specific namespace.
-	var searchNamespace string
-	if a.Globals.Namespace != "" {
-		// use the provided namespace
-		searchNamespace = a.Globals.Namespace
-	} else {
-		// search all namespaces
+	// Search all namespaces unless one was explicitly provided.
+	searchNamespace := a.Globals.Namespace
+	if a.Globals.NamespaceFromContext {
 		searchNamespace = ""
 	}
 
-	// Labels used to match Sonar resources.
+	// Labels used to match Sonar sessions.
 	searchLabels := []string{"owner=sonar"}
 
 	// Add the provided name to the search labels if it is not empty.
-	if a.Globals.Name != "" {
-		// add the name to the search labels - we use the full name value as
-		// this is what the pod is actually labelled with.
+	if nameProvided {
 		searchLabels = append(searchLabels, fmt.Sprintf("name=%s", a.Globals.Name))
 	}
 
-	// Create a context
-	ctx := context.TODO()
+	// Add any user-provided selector.
+	if selector != "" {
+		searchLabels = append(searchLabels, selector)
+	}
+
+	// Fan out to each cluster if multiple contexts were selected.
+	if len(a.Globals.KubeContexts) > 1 {
+		if !nameProvided && !all {
+			return fmt.Errorf("--name or --all must be provided when using multiple contexts")
+		}
+		return runMultiContextDelete(a, searchNamespace, searchLabels)
+	}
+
+	// Create a Kubernetes clientset.
+	k8sClientSet, err := k8sclient.New(a.Globals.KubeContext, a.Globals.KubeConfig)
+	if err != nil {
+		return err
+	}
+
+	// Use the root context so that the user can interrupt Sonar
+	ctx := a.Context
 
-	// Find all Sonar deployments.
-	discoveredDeployments := utils.FindSonarDeployments(k8sClientSet, ctx, a.Globals.Name, searchNamespace, searchLabels)
+	// Find all Sonar sessions which match the filters.
+	discoveredSessions, err := utils.FindSonarSessions(k8sClientSet, ctx, a.Globals.Name, searchNamespace, searchLabels)
+	if err != nil {
+		return writeResult(a, nil, err)
+	}
 
-	var selectedDeploy string
-	if !skipInteractiveLookup {
-		// Build a list of deployments to pass to the selection prompt.
-		var deployList []string
-		for _, deploy := range discoveredDeployments {
-			deployList = append(deployList, fmt.Sprintf("%s/%s", deploy.Namespace, deploy.Name))
+	discoveredSessions = filterSessions(discoveredSessions)
+	if len(discoveredSessions) == 0 {
+		// Only the age filter can leave no sessions at this point.
+		if olderThan > 0 {
+			return writeResult(a, nil, fmt.Errorf("no sessions older than %s found: %w", olderThan, utils.ErrNoSessions))
 		}
+		return writeResult(a, nil, utils.ErrNoSessions)
+	}
 
-		// Prompt the user to select which deployment to delete.
-		prompt := "Select deployment to delete"
-		selectedDeploy, err = utils.DisplaySelectionPrompt(prompt, deployList)
+	// Use the matching sessions directly if --all was set or the name
+	// only matched one, otherwise prompt the user to select which
+	// sessions to delete.
+	selected := discoveredSessions
+	if !all && (!nameProvided || len(discoveredSessions) > 1) {
+		selected, err = selectSessions(discoveredSessions)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
-
-		// Trim the namespace from the selected deployment.
-		_, selectedDeploy, _ = strings.Cut(selectedDeploy, "/")
-	} else {
-		// If the user provided a name, we use that as the selected deployment.
-		selectedDeploy = a.Globals.FullName
 	}
 
-	// Inform the user of the selected pod
-	log.Infof("Deployment to be deleted: %s", selectedDeploy)
+	// Inform the user of the selected sessions
+	for _, s := range selected {
+		log.Infof("Session to be deleted: %s/%s (%s)", s.Namespace, s.Name, s.Kind)
+	}
 
-	// Find the namespace of the victim deployment.
-	var selectedDeployNamespace string
-	for _, d := range discoveredDeployments {
-		if d.Name == selectedDeploy {
-			selectedDeployNamespace = d.Namespace
-			break
-		}
+	// Build a single plan covering every selected session.
+	plan, err := PlanSessions(k8sClientSet, ctx, selected)
+	if err != nil {
+		return err
 	}
 
-	// Instantiate a DeleteConfig struct.
-	opts := config.DeleteConfig{
-		SearchLabels: searchLabels,
-		Name:         selectedDeploy,
-		Namespace:    selectedDeployNamespace,
+	if len(plan) == 0 {
+		log.Info("no resources were found")
+		return writeResult(a, nil, nil)
 	}
 
 	if force {
 		log.Info("force was set, not asking for confirmation before deleting resources")
+	} else {
+		if err := printPlan(planOutput(), plan); err != nil {
+			return err
+		}
+
+		ok, err := utils.ConfirmationPrompt(fmt.Sprintf("%d resources of %d sessions", len(plan), len(selected)), strings.Join(sessionNames(selected), ", "))
+		if err != nil || !ok {
+			return err
+		}
+	}
+
+	results, deleteErr := Delete(k8sClientSet, ctx, plan)
+
+	// Always report what happened to each resource, even if some failed.
+	if output != "" {
+		return writeResult(a, results, deleteErr)
+	}
+	if err := printResults(os.Stdout, results); err != nil {
+		return err
 	}
 
-	// Collect any errors.
-	var errs []error
+	return deleteErr
+}
 
-	// Initialise an empty map to report deleted resources to user
-	// at the end.
-	deletedResources := []string{}
+// writeResult writes a result document for the deleted resources if one
+// was requested, and returns err.
+func writeResult(a *app.App, results []types.ResourceResult, err error) error {
+	if output == "" {
+		return err
+	}
 
-	// Convert opts.Labels into a format suitable for use as a LabelSelector.
-	//var labelSlice = []string{}
-	//for k, v := range opts.SearchLabels {
-	//	labelSlice = append(labelSlice, fmt.Sprintf("%s=%s", k, v))
-	//}
+	cluster, _ := k8sclient.ContextName(a.Globals.KubeConfig, a.Globals.KubeContext)
+	result := report.New("destroy")
+	result.Add(cluster, results)
+	result.AddError(err)
+
+	return errors.Join(err, result.Write(os.Stdout, output))
+}
 
-	// Filter resources by Sonar labels.
-	listOpts := metav1.ListOptions{
-		LabelSelector: strings.Join(opts.SearchLabels, ","),
+// planOutput returns where the plan is written: stdout, unless it
+// carries a result document.
+func planOutput() io.Writer {
+	if output != "" {
+		return os.Stderr
 	}
 
-	// Delete the deployment
-	delDeploy, deployErr := deleteDeployment(k8sClientSet, ctx, opts, force)
-	if deployErr != nil {
-		errs = append(errs, deployErr)
+	return os.Stdout
+}
+
+// filterSessions drops any sessions which are newer than --older-than.
+func filterSessions(sessions []types.DiscoveredSession) []types.DiscoveredSession {
+	if olderThan == 0 {
+		return sessions
 	}
-	// If the deployment was deleted successfully, add it to the list of deleted resources.
-	if len(delDeploy) > 0 {
-		deletedResources = append(deletedResources, delDeploy)
+
+	var filtered []types.DiscoveredSession
+	cutoff := time.Now().Add(-olderThan)
+	for _, s := range sessions {
+		if s.Created.Before(cutoff) {
+			filtered = append(filtered, s)
+		}
 	}
 
-	// Get NetworkPolicies and see if a match is found
-	inClusterNps := []networkingv1.NetworkPolicy{}
-	nps, err := k8sClientSet.NetworkingV1().NetworkPolicies(opts.Namespace).List(ctx, listOpts)
+	return filtered
+}
+
+// selectSessions prompts the user to select one or more sessions.
+func selectSessions(sessions []types.DiscoveredSession) ([]types.DiscoveredSession, error) {
+	names := sessionNames(sessions)
+
+	// Prompt the user to select which sessions to delete.
+	prompt := "Select sessions to delete"
+	selectedNames, err := utils.DisplayMultiSelectionPrompt(prompt, names)
 	if err != nil {
-		log.Warnf("%v", err)
+		return nil, err
 	}
-	inClusterNps = append(inClusterNps, nps.Items...)
 
-	// Range over discovered NetworkPolicies and see if any match.
-	for _, np := range inClusterNps {
-		if strings.HasPrefix(np.Name, opts.Name) {
-			// Delete the NetworkPolicy
-			delNP, npErr := deleteNetworkPolicy(k8sClientSet, ctx, opts, force)
-			if npErr != nil {
-				errs = append(errs, npErr)
-			}
-			// If the NetworkPolicy was deleted successfully, add it to the list of deleted resources.
-			if len(delNP) > 0 {
-				deletedResources = append(deletedResources, delNP)
+	var selected []types.DiscoveredSession
+	for _, name := range selectedNames {
+		for i, s := range sessions {
+			if names[i] == name {
+				selected = append(selected, s)
 			}
 		}
 	}
 
-	// Delete the ServiceAccount
-	delSA, saErr := deleteServiceAccount(k8sClientSet, ctx, opts, force)
-	if saErr != nil {
-		errs = append(errs, saErr)
-	}
-	// If the ServiceAccount was deleted successfully, add it to the list of deleted resources.
-	if len(delSA) > 0 {
-		deletedResources = append(deletedResources, delSA)
-	}
-
-	// If there were any validation errors, return them as a single error.
-	if len(errs) > 0 {
-		return errors.Join(errs...)
-	}
+	return selected, nil
+}
 
-	if len(deletedResources) > 0 {
-		log.Infof("resources deleted: %s", strings.Join(deletedResources, ", "))
-	} else {
-		log.Info("no resources were deleted")
+// sessionNames returns the sessions in "namespace/name" form.
+func sessionNames(sessions []types.DiscoveredSession) []string {
+	var names []string
+	for _, s := range sessions {
+		names = append(names, fmt.Sprintf("%s/%s", s.Namespace, s.Name))
 	}
 
-	return nil
+	return names
 }
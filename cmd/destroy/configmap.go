@@ -0,0 +1,50 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package destroy
+
+import (
+	"context"
+
+	"github.com/glitchcrab/sonar/internal/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// configMapKind is the session's anchor, which owns every other resource,
+// along with any ConfigMaps which it owns (such as the session's scripts).
+var configMapKind = resourceKind{
+	kind:   "configmap",
+	list:   listConfigMaps,
+	delete: deleteConfigMap,
+}
+
+func listConfigMaps(k8sClientSet *kubernetes.Clientset, ctx context.Context, namespace string, listOpts metav1.ListOptions) ([]types.SessionResource, error) {
+	cms, err := k8sClientSet.CoreV1().ConfigMaps(namespace).List(ctx, listOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []types.SessionResource
+	for _, cm := range cms.Items {
+		resources = append(resources, types.SessionResource{Kind: "configmap", Namespace: cm.Namespace, Name: cm.Name, Owner: anchorOf(cm.Namespace, cm.OwnerReferences)})
+	}
+
+	return resources, nil
+}
+
+func deleteConfigMap(k8sClientSet *kubernetes.Clientset, ctx context.Context, r types.SessionResource, deleteOpts metav1.DeleteOptions) error {
+	return k8sClientSet.CoreV1().ConfigMaps(r.Namespace).Delete(ctx, r.Name, deleteOpts)
+}
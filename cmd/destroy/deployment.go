@@ -17,49 +17,32 @@ package destroy
 
 import (
 	"context"
-	"fmt"
 
-	"github.com/glitchcrab/sonar/internal/config"
-	"github.com/glitchcrab/sonar/internal/utils"
-	log "github.com/sirupsen/logrus"
-	"k8s.io/apimachinery/pkg/api/errors"
+	"github.com/glitchcrab/sonar/internal/types"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func deleteDeployment(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.DeleteConfig, force bool) (string, error) {
-	// Set foreground deletion so the client waits for confirmation before proceeding
-	deletePolicy := metav1.DeletePropagationForeground
-	deleteOptions := metav1.DeleteOptions{
-		PropagationPolicy: &deletePolicy,
-	}
-
-	resourceType := "deployment"
-	name := fmt.Sprintf("%s/%s", o.Namespace, o.Name)
+var deploymentKind = resourceKind{
+	kind:   "deployment",
+	list:   listDeployments,
+	delete: deleteDeployment,
+}
 
-	var err error
-	var ok bool
-	if !force {
-		ok = utils.ConfirmationPrompt(resourceType, name)
-	} else {
-		ok = true
-	}
-	if ok {
-		err = k8sClientSet.AppsV1().Deployments(o.Namespace).Delete(ctx, o.Name, deleteOptions)
+func listDeployments(k8sClientSet *kubernetes.Clientset, ctx context.Context, namespace string, listOpts metav1.ListOptions) ([]types.SessionResource, error) {
+	deployments, err := k8sClientSet.AppsV1().Deployments(namespace).List(ctx, listOpts)
+	if err != nil {
+		return nil, err
 	}
 
-	// Handle errors
-	if statusError, isStatus := err.(*errors.StatusError); isStatus && statusError.Status().Reason == metav1.StatusReasonNotFound {
-		// Skip deletion of this resource
-		log.Info("no matching deployment found; skipping deletion")
-		return "", nil
-	} else if err != nil {
-		// Only return an error if the resource was not deleted
-		return "", fmt.Errorf("deployment \"%s/%s\" failed deletion: %w", o.Namespace, o.Name, err)
-	} else {
-		// Inform the user that the resource was deleted
-		log.Infof("deleting deployment")
+	var resources []types.SessionResource
+	for _, d := range deployments.Items {
+		resources = append(resources, types.SessionResource{Kind: "deployment", Namespace: d.Namespace, Name: d.Name, Owner: anchorOf(d.Namespace, d.OwnerReferences)})
 	}
 
-	return resourceType, nil
+	return resources, nil
+}
+
+func deleteDeployment(k8sClientSet *kubernetes.Clientset, ctx context.Context, r types.SessionResource, deleteOpts metav1.DeleteOptions) error {
+	return k8sClientSet.AppsV1().Deployments(r.Namespace).Delete(ctx, r.Name, deleteOpts)
 }
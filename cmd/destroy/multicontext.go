@@ -0,0 +1,173 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package destroy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/glitchcrab/sonar/internal/app"
+	"github.com/glitchcrab/sonar/internal/fanout"
+	"github.com/glitchcrab/sonar/internal/k8sclient"
+	"github.com/glitchcrab/sonar/internal/report"
+	"github.com/glitchcrab/sonar/internal/types"
+	"github.com/glitchcrab/sonar/internal/utils"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// runMultiContextDelete deletes the matching sessions from every selected
+// context concurrently. Interactive selection is not possible across
+// clusters, so the combined plan is displayed and a single confirmation is
+// requested up front instead.
+func runMultiContextDelete(a *app.App, searchNamespace string, searchLabels []string) error {
+	contexts := a.Globals.KubeContexts
+
+	log.Infof("searching for sessions matching %s (contexts: %s)", strings.Join(searchLabels, ","), strings.Join(contexts, ", "))
+
+	searchOpts := metav1.ListOptions{
+		LabelSelector: strings.Join(searchLabels, ","),
+	}
+
+	// Build the plan for each cluster.
+	ctx := a.Context
+	plans := fanout.Run(ctx, contexts, a.Globals.ClusterTimeout, func(ctx context.Context, kubeContext string) ([]types.SessionResource, error) {
+		// Create a Kubernetes clientset.
+		k8sClientSet, err := k8sclient.New(kubeContext, a.Globals.KubeConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions, err := utils.ListSonarSessions(k8sClientSet, ctx, searchNamespace, searchOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		return PlanSessions(k8sClientSet, ctx, filterSessions(sessions))
+	})
+
+	// Display the combined plan. Clusters which could not be planned are
+	// listed too, so that the user knows about them before confirming.
+	var found, failed int
+	w := tabwriter.NewWriter(planOutput(), 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "CONTEXT\tKIND\tNAMESPACE\tNAME\tERROR")
+	for _, p := range plans {
+		if p.Err != nil {
+			fmt.Fprintf(w, "%s\t%s\t\t\t%v\n", p.Context, types.ActionFailed, p.Err)
+			failed++
+			continue
+		}
+		for _, r := range p.Value {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", p.Context, r.Kind, r.Namespace, r.Name)
+			found++
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		log.Warnf("%d of %d contexts could not be planned and will not be changed", failed, len(contexts))
+	}
+
+	if found == 0 {
+		log.Info("no resources were found")
+	} else if force {
+		log.Info("force was set, not asking for confirmation before deleting resources")
+	} else {
+		ok, err := utils.ConfirmationPrompt(fmt.Sprintf("%d resources in %d contexts", found, len(contexts)-failed), strings.Join(searchLabels, ","))
+		if err != nil || !ok {
+			return err
+		}
+	}
+
+	// Carry the plan for each cluster through to the deletion, so that
+	// clusters which could not be planned are still reported.
+	planFor := make(map[string]fanout.Result[[]types.SessionResource])
+	for _, p := range plans {
+		planFor[p.Context] = p
+	}
+
+	results := fanout.Run(ctx, contexts, a.Globals.ClusterTimeout, func(ctx context.Context, kubeContext string) ([]types.ResourceResult, error) {
+		p := planFor[kubeContext]
+		if p.Err != nil {
+			return nil, fmt.Errorf("could not plan deletion: %w", p.Err)
+		}
+		if len(p.Value) == 0 {
+			return nil, nil
+		}
+
+		// Create a Kubernetes clientset.
+		k8sClientSet, err := k8sclient.New(kubeContext, a.Globals.KubeConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		return Delete(k8sClientSet, ctx, p.Value)
+	})
+
+	if output != "" {
+		return writeMultiContextResult(results)
+	}
+
+	// Report what happened to each resource in each cluster, along with any
+	// clusters which failed outright.
+	var errs []error
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "CONTEXT\tKIND\tNAMESPACE\tNAME\tRESULT\tERROR")
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("context %q: %w", result.Context, result.Err))
+		}
+		if result.Err != nil && len(result.Value) == 0 {
+			fmt.Fprintf(w, "%s\t\t\t\t%s\t%v\n", result.Context, types.ActionFailed, result.Err)
+			continue
+		}
+		for _, r := range result.Value {
+			errMsg := ""
+			if r.Err != nil {
+				errMsg = r.Err.Error()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", result.Context, r.Kind, r.Namespace, r.Name, r.Action, errMsg)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// writeMultiContextResult writes a result document covering every
+// cluster, and returns the errors of any clusters which failed.
+func writeMultiContextResult(results []fanout.Result[[]types.ResourceResult]) error {
+	var errs []error
+	result := report.New("destroy")
+	for _, r := range results {
+		result.Add(r.Context, r.Value)
+		if r.Err != nil {
+			err := fmt.Errorf("context %q: %w", r.Context, r.Err)
+			result.AddError(err)
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errors.Join(errs...), result.Write(os.Stdout, output))
+}
@@ -17,49 +17,32 @@ package destroy
 
 import (
 	"context"
-	"fmt"
 
-	"github.com/glitchcrab/sonar/internal/config"
-	"github.com/glitchcrab/sonar/internal/utils"
-	log "github.com/sirupsen/logrus"
-	"k8s.io/apimachinery/pkg/api/errors"
+	"github.com/glitchcrab/sonar/internal/types"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func deleteNetworkPolicy(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.DeleteConfig, force bool) (string, error) {
-	// Set foreground deletion so the client waits for confirmation before proceeding
-	deletePolicy := metav1.DeletePropagationForeground
-	deleteOptions := metav1.DeleteOptions{
-		PropagationPolicy: &deletePolicy,
-	}
-
-	resourceType := "networkpolicy"
-	name := fmt.Sprintf("%s/%s", o.Namespace, o.Name)
+var networkPolicyKind = resourceKind{
+	kind:   "networkpolicy",
+	list:   listNetworkPolicies,
+	delete: deleteNetworkPolicy,
+}
 
-	var err error
-	var ok bool
-	if !force {
-		ok = utils.ConfirmationPrompt(resourceType, name)
-	} else {
-		ok = true
-	}
-	if ok {
-		err = k8sClientSet.NetworkingV1().NetworkPolicies(o.Namespace).Delete(ctx, o.Name, deleteOptions)
+func listNetworkPolicies(k8sClientSet *kubernetes.Clientset, ctx context.Context, namespace string, listOpts metav1.ListOptions) ([]types.SessionResource, error) {
+	nps, err := k8sClientSet.NetworkingV1().NetworkPolicies(namespace).List(ctx, listOpts)
+	if err != nil {
+		return nil, err
 	}
 
-	// Handle errors
-	if statusError, isStatus := err.(*errors.StatusError); isStatus && statusError.Status().Reason == metav1.StatusReasonNotFound {
-		// Skip deletion of this resource
-		log.Info("no matching networkpolicy found; skipping deletion")
-		return "", nil
-	} else if err != nil {
-		// Only return an error if the resource was not deleted
-		return "", fmt.Errorf("networkpolicy \"%s\" failed deletion: %w", o.Name, err)
-	} else {
-		// Inform the user that the resource was deleted
-		log.Infof("deleting networkpolicy")
+	var resources []types.SessionResource
+	for _, np := range nps.Items {
+		resources = append(resources, types.SessionResource{Kind: "networkpolicy", Namespace: np.Namespace, Name: np.Name, Owner: anchorOf(np.Namespace, np.OwnerReferences)})
 	}
 
-	return resourceType, nil
+	return resources, nil
+}
+
+func deleteNetworkPolicy(k8sClientSet *kubernetes.Clientset, ctx context.Context, r types.SessionResource, deleteOpts metav1.DeleteOptions) error {
+	return k8sClientSet.NetworkingV1().NetworkPolicies(r.Namespace).Delete(ctx, r.Name, deleteOpts)
 }
@@ -0,0 +1,242 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package destroy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/glitchcrab/sonar/internal/config"
+	"github.com/glitchcrab/sonar/internal/logging"
+	"github.com/glitchcrab/sonar/internal/types"
+	"github.com/glitchcrab/sonar/internal/utils"
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// resourceKind describes a kind of resource which Sonar creates, and how
+// to find and delete it.
+type resourceKind struct {
+	kind   string
+	list   func(k8sClientSet *kubernetes.Clientset, ctx context.Context, namespace string, listOpts metav1.ListOptions) ([]types.SessionResource, error)
+	delete func(k8sClientSet *kubernetes.Clientset, ctx context.Context, r types.SessionResource, deleteOpts metav1.DeleteOptions) error
+}
+
+// resourceKinds lists every kind of resource which Sonar creates, in the
+// order in which they are deleted. The anchor comes first so that deleting
+// it cascades to its dependents; the remaining kinds are deleted
+// dependents first, for sessions created before anchors existed.
+var resourceKinds = []resourceKind{
+	configMapKind,
+	deploymentKind,
+	jobKind,
+	podKind,
+	networkPolicyKind,
+	roleBindingKind,
+	roleKind,
+	secretKind,
+	serviceAccountKind,
+}
+
+// Plan finds every resource belonging to the session matched by the
+// DeleteConfig, in the order in which they should be deleted.
+func Plan(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.DeleteConfig) ([]types.SessionResource, error) {
+	// Filter resources by the session's labels.
+	listOpts := metav1.ListOptions{
+		LabelSelector: strings.Join(o.SearchLabels, ","),
+	}
+
+	var errs []error
+	var plan []types.SessionResource
+	for _, k := range resourceKinds {
+		resources, err := k.list(k8sClientSet, ctx, o.Namespace, listOpts)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not list %ss: %w", k.kind, err))
+			continue
+		}
+		plan = append(plan, resources...)
+	}
+
+	return plan, errors.Join(errs...)
+}
+
+// Delete deletes the planned resources in order and returns the outcome
+// for each resource.
+func Delete(k8sClientSet *kubernetes.Clientset, ctx context.Context, plan []types.SessionResource) ([]types.ResourceResult, error) {
+	// Set foreground deletion so that dependents are removed before the owner
+	deletePolicy := metav1.DeletePropagationForeground
+	deleteOpts := metav1.DeleteOptions{
+		PropagationPolicy: &deletePolicy,
+	}
+
+	var errs []error
+	var results []types.ResourceResult
+	deleted := make(map[string]bool)
+	for _, r := range plan {
+		result := types.ResourceResult{SessionResource: r, Action: types.ActionDeleted}
+
+		k, ok := kindFor(r.Kind)
+		if r.Owner != "" && deleted[r.Owner] {
+			// The garbage collector deletes it along with its anchor.
+			logging.Resource(ctx, r.Namespace, r.Kind, r.Name, types.ActionDeleted).Infof("deleting %s along with %s", r, r.Owner)
+		} else if !ok {
+			result.Action = types.ActionFailed
+			result.Err = fmt.Errorf("%s: unknown resource kind", r)
+		} else if err := k.delete(k8sClientSet, ctx, r, deleteOpts); apierrors.IsNotFound(err) {
+			// Skip deletion of this resource
+			logging.Resource(ctx, r.Namespace, r.Kind, r.Name, types.ActionSkipped).Infof("%s no longer exists; skipping deletion", r)
+			result.Action = types.ActionSkipped
+		} else if err != nil {
+			result.Action = types.ActionFailed
+			result.Err = fmt.Errorf("%s failed deletion: %w", r, err)
+		} else {
+			logging.Resource(ctx, r.Namespace, r.Kind, r.Name, types.ActionDeleted).Infof("deleting %s", r)
+		}
+
+		if result.Err != nil {
+			errs = append(errs, result.Err)
+		} else {
+			deleted[r.String()] = true
+		}
+		results = append(results, result)
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// Resources finds all of the resources which make up a Sonar session,
+// asks the user to confirm the plan (unless force is set) and deletes them.
+func Resources(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.DeleteConfig, force bool) ([]types.ResourceResult, error) {
+	plan, err := Plan(k8sClientSet, ctx, o)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(plan) == 0 {
+		log.WithContext(ctx).Infof("no resources found for session %s", o.Name)
+		return nil, nil
+	}
+
+	if !force {
+		if err := printPlan(os.Stdout, plan); err != nil {
+			return nil, err
+		}
+
+		ok, err := utils.ConfirmationPrompt(fmt.Sprintf("%d resources of session", len(plan)), o.Name)
+		if err != nil || !ok {
+			return nil, err
+		}
+	}
+
+	return Delete(k8sClientSet, ctx, plan)
+}
+
+// PlanSessions builds a single plan covering all of the provided sessions.
+// Resources which are matched by more than one session only appear once.
+func PlanSessions(k8sClientSet *kubernetes.Clientset, ctx context.Context, sessions []types.DiscoveredSession) ([]types.SessionResource, error) {
+	var errs []error
+	var plan []types.SessionResource
+	seen := make(map[types.SessionResource]bool)
+	for _, s := range sessions {
+		resources, err := Plan(k8sClientSet, ctx, config.NewDeleteConfig(s.Namespace, s.Name, s.Labels))
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		for _, r := range resources {
+			if !seen[r] {
+				seen[r] = true
+				plan = append(plan, r)
+			}
+		}
+	}
+
+	// Keep dependents ahead of the resources they depend on across sessions.
+	sort.SliceStable(plan, func(i, j int) bool {
+		return kindOrder(plan[i].Kind) < kindOrder(plan[j].Kind)
+	})
+
+	return plan, errors.Join(errs...)
+}
+
+// printPlan writes the resources which will be deleted to w.
+func printPlan(w io.Writer, plan []types.SessionResource) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tNAMESPACE\tNAME")
+	for _, r := range plan {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Kind, r.Namespace, r.Name)
+	}
+
+	return tw.Flush()
+}
+
+// printResults writes the outcome for each resource to w, one resource per
+// line, so that it can be consumed by scripts.
+func printResults(w io.Writer, results []types.ResourceResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tNAMESPACE\tNAME\tRESULT\tERROR")
+	for _, r := range results {
+		errMsg := ""
+		if r.Err != nil {
+			errMsg = r.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Kind, r.Namespace, r.Name, r.Action, errMsg)
+	}
+
+	return tw.Flush()
+}
+
+// anchorOf returns the String() form of the session anchor which owns a
+// resource, or an empty string if it isn't owned by one.
+func anchorOf(namespace string, refs []metav1.OwnerReference) string {
+	for _, ref := range refs {
+		if ref.APIVersion == "v1" && ref.Kind == "ConfigMap" {
+			return types.SessionResource{Kind: "configmap", Namespace: namespace, Name: ref.Name}.String()
+		}
+	}
+
+	return ""
+}
+
+// kindFor returns the resourceKind with the provided name.
+func kindFor(kind string) (resourceKind, bool) {
+	for _, k := range resourceKinds {
+		if k.kind == kind {
+			return k, true
+		}
+	}
+
+	return resourceKind{}, false
+}
+
+// kindOrder returns the position of a kind in the deletion order.
+func kindOrder(kind string) int {
+	for i, k := range resourceKinds {
+		if k.kind == kind {
+			return i
+		}
+	}
+
+	return len(resourceKinds)
+}
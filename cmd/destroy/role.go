@@ -0,0 +1,48 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package destroy
+
+import (
+	"context"
+
+	"github.com/glitchcrab/sonar/internal/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var roleKind = resourceKind{
+	kind:   "role",
+	list:   listRoles,
+	delete: deleteRole,
+}
+
+func listRoles(k8sClientSet *kubernetes.Clientset, ctx context.Context, namespace string, listOpts metav1.ListOptions) ([]types.SessionResource, error) {
+	roles, err := k8sClientSet.RbacV1().Roles(namespace).List(ctx, listOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []types.SessionResource
+	for _, role := range roles.Items {
+		resources = append(resources, types.SessionResource{Kind: "role", Namespace: role.Namespace, Name: role.Name, Owner: anchorOf(role.Namespace, role.OwnerReferences)})
+	}
+
+	return resources, nil
+}
+
+func deleteRole(k8sClientSet *kubernetes.Clientset, ctx context.Context, r types.SessionResource, deleteOpts metav1.DeleteOptions) error {
+	return k8sClientSet.RbacV1().Roles(r.Namespace).Delete(ctx, r.Name, deleteOpts)
+}
@@ -17,49 +17,32 @@ package destroy
 
 import (
 	"context"
-	"fmt"
 
-	"github.com/glitchcrab/sonar/internal/config"
-	"github.com/glitchcrab/sonar/internal/utils"
-	log "github.com/sirupsen/logrus"
-	"k8s.io/apimachinery/pkg/api/errors"
+	"github.com/glitchcrab/sonar/internal/types"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func deleteServiceAccount(k8sClientSet *kubernetes.Clientset, ctx context.Context, o config.DeleteConfig, force bool) (string, error) {
-	// Set foreground deletion so the client waits for confirmation before proceeding
-	deletePolicy := metav1.DeletePropagationForeground
-	deleteOptions := metav1.DeleteOptions{
-		PropagationPolicy: &deletePolicy,
-	}
-
-	resourceType := "serviceaccount"
-	name := fmt.Sprintf("%s/%s", o.Namespace, o.Name)
+var serviceAccountKind = resourceKind{
+	kind:   "serviceaccount",
+	list:   listServiceAccounts,
+	delete: deleteServiceAccount,
+}
 
-	var err error
-	var ok bool
-	if !force {
-		ok = utils.ConfirmationPrompt(resourceType, name)
-	} else {
-		ok = true
-	}
-	if ok {
-		err = k8sClientSet.CoreV1().ServiceAccounts(o.Namespace).Delete(ctx, o.Name, deleteOptions)
+func listServiceAccounts(k8sClientSet *kubernetes.Clientset, ctx context.Context, namespace string, listOpts metav1.ListOptions) ([]types.SessionResource, error) {
+	sas, err := k8sClientSet.CoreV1().ServiceAccounts(namespace).List(ctx, listOpts)
+	if err != nil {
+		return nil, err
 	}
 
-	// Handle errors
-	if statusError, isStatus := err.(*errors.StatusError); isStatus && statusError.Status().Reason == metav1.StatusReasonNotFound {
-		// Skip deletion of this resource
-		log.Info("no matching serviceaccount found; skipping deletion")
-		return "", nil
-	} else if err != nil {
-		// Only return an error if the resource was not deleted
-		return "", fmt.Errorf("serviceaccount \"%s/%s\" failed deletion: %w", o.Namespace, o.Name, err)
-	} else {
-		// Inform the user that the resource was deleted
-		log.Infof("deleting serviceaccount")
+	var resources []types.SessionResource
+	for _, sa := range sas.Items {
+		resources = append(resources, types.SessionResource{Kind: "serviceaccount", Namespace: sa.Namespace, Name: sa.Name, Owner: anchorOf(sa.Namespace, sa.OwnerReferences)})
 	}
 
-	return resourceType, err
+	return resources, nil
+}
+
+func deleteServiceAccount(k8sClientSet *kubernetes.Clientset, ctx context.Context, r types.SessionResource, deleteOpts metav1.DeleteOptions) error {
+	return k8sClientSet.CoreV1().ServiceAccounts(r.Namespace).Delete(ctx, r.Name, deleteOpts)
 }
@@ -1,19 +1,25 @@
 package exec
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/glitchcrab/sonar/internal/app"
 	"github.com/glitchcrab/sonar/internal/k8sclient"
-	"github.com/glitchcrab/sonar/internal/types"
+	"github.com/glitchcrab/sonar/internal/recording"
 	"github.com/glitchcrab/sonar/internal/utils"
+	"github.com/mitchellh/go-homedir"
+	"github.com/moby/term"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	noRecord bool
+	record   string
 )
 
 func NewCommand() *cobra.Command {
@@ -23,8 +29,10 @@ func NewCommand() *cobra.Command {
 		Long: `Exec attempts to exec into a Sonar debug container using the current
 (or provided) kubectl context. It searches for pods in the currently
 selected namespace with the label 'owner=sonar' and prompts the user
-to select a pod to exec into. The user can scope the selection by
-providing a namespace via the --namespace/-n flag.
+to select a pod to exec into. Pods of every kind of session
+(deployment, job or pod) are matched, as long as they are running. The
+user can scope the selection by providing a namespace via the
+--namespace/-n flag.
 
 By default, the exec command will run /bin/sh in the target pod, however
 any command can be provided after a '--' separator. For example:
@@ -40,16 +48,38 @@ All flags are optional.
 
 Global flags:
 
-Run "sonar help" in order to see flags which apply to all subcommands.`,
+Run "sonar help" in order to see flags which apply to all subcommands.
+
+Flags:
+
+--record (default: none)
+
+Records the session to the provided file in asciinema v2 format,
+including the input, the output and any changes to the terminal's size.
+Recordings can be replayed with "asciinema play <file>".
+
+Setting 'record-dir' in the config file records every session into
+that directory, in files named after the cluster, namespace, pod and
+time (e.g. 'prod_default_sonar-debug-5d9c_20261019T120000Z.cast').
+
+--no-record (default: false)
+
+Doesn't record the session, even if 'record-dir' is set.`,
 		Example: `
 "sonar exec" - finds all Sonar pods across all namespaces.
 
 "sonar exec --namespace kube-system" - finds all Sonar pods in
-namespace 'kube-system'.`,
+namespace 'kube-system'.
+
+"sonar exec --record incident-123.cast" - records the session to
+incident-123.cast.`,
 		SilenceUsage: true,
 		RunE:         runExecCommand,
 	}
 
+	command.Flags().BoolVar(&noRecord, "no-record", false, "don't record the session, even if record-dir is set")
+	command.Flags().StringVar(&record, "record", "", "record the session to the provided file in asciinema v2 format")
+
 	return command
 }
 
@@ -60,39 +90,26 @@ func runExecCommand(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if err := a.Globals.RequireSingleContext("exec"); err != nil {
+		return err
+	}
+
 	// Create a Kubernetes clientset.
 	k8sClientSet, err := k8sclient.New(a.Globals.KubeContext, a.Globals.KubeConfig)
 	if err != nil {
 		return err
 	}
 
-	// Assemble lookup options
-
 	// Labels used to match Sonar containers.
 	searchLabels := []string{"owner=sonar"}
 
-	// Create a label selector string from the search labels.
-	searchOpts := metav1.ListOptions{
-		LabelSelector: strings.Join(searchLabels, ","),
-	}
-
-	// Get all pods in the cluster matching the search options.
-	ctx := context.TODO()
-	pods, err := k8sClientSet.CoreV1().Pods(a.Globals.Namespace).List(ctx, searchOpts)
+	// Get all pods in the cluster matching the search labels.
+	ctx := a.Context
+	discoveredPods, err := utils.FindSonarPods(k8sClientSet, ctx, a.Globals.Name, a.Globals.Namespace, searchLabels)
 	if err != nil {
 		return err
 	}
 
-	// Add all discovered pods to the list of discovered pods.
-	var discoveredPods []types.DiscoveredPod
-	for _, pod := range pods.Items {
-		discoveredPods = append(discoveredPods, types.DiscoveredPod{
-			Name:      pod.Name,
-			Namespace: pod.Namespace,
-			Status:    pod.Status.Phase,
-		})
-	}
-
 	// Filter discovered pods to only include those in Running state, then create a list to pass to the selection prompt.
 	var podList []string
 	for _, pod := range discoveredPods {
@@ -101,14 +118,12 @@ func runExecCommand(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Raise a clean exit if no pods found.
+	// Return a typed error if no pods are running.
 	if len(podList) == 0 {
 		if a.Globals.Namespace != "" {
-			log.Infof("no running pods found with labels %s in namespace %s", strings.Join(searchLabels, ","), a.Globals.Namespace)
-		} else {
-			log.Infof("no running pods found with labels %s across all namespaces", strings.Join(searchLabels, ","))
+			return fmt.Errorf("no running pods found with labels %s in namespace %s: %w", strings.Join(searchLabels, ","), a.Globals.Namespace, utils.ErrNoSessions)
 		}
-		return nil
+		return fmt.Errorf("no running pods found with labels %s across all namespaces: %w", strings.Join(searchLabels, ","), utils.ErrNoSessions)
 	}
 
 	// Prompt the user to select which pod to exec into.
@@ -128,7 +143,7 @@ func runExecCommand(cmd *cobra.Command, args []string) error {
 	if cmd.ArgsLenAtDash() < 0 {
 		dynamicCommand, err := utils.PromptForInput("Enter the command to run in the pod (default: /bin/sh): ")
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 
 		// If the user provided a command then use it, otherwise default to /bin/sh.
@@ -166,11 +181,68 @@ func runExecCommand(cmd *cobra.Command, args []string) error {
 	// Get stdin's file descriptor.
 	fd := os.Stdin.Fd()
 
+	// Record the session if requested.
+	v, err := app.GetViper(cmd)
+	if err != nil {
+		return err
+	}
+
+	path := record
+	if path == "" && !noRecord && v.GetString("record-dir") != "" {
+		path, err = recordingPath(v.GetString("record-dir"), a.Globals.KubeConfig, a.Globals.KubeContext, targetNamespace, targetPod)
+		if err != nil {
+			return err
+		}
+	}
+
+	var rec *recording.Recorder
+	if path != "" {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return fmt.Errorf("could not create recording: %w", err)
+		}
+		defer f.Close()
+
+		// Start with the terminal's current size.
+		var width, height int
+		if ws, err := term.GetWinsize(fd); err == nil {
+			width, height = int(ws.Width), int(ws.Height)
+		}
+
+		title := fmt.Sprintf("%s/%s: %s", targetNamespace, targetPod, strings.Join(podCommand, " "))
+		rec, err = recording.New(f, width, height, title, map[string]string{"TERM": os.Getenv("TERM")})
+		if err != nil {
+			return err
+		}
+
+		log.Infof("recording session to %s", path)
+	}
+
 	// Exec into the pod.
-	err = exec(ctx, k8sClientSet, restClient, targetPod, targetNamespace, podCommand, fd, os.Stdin, os.Stdout, os.Stderr)
+	err = exec(ctx, k8sClientSet, restClient, targetPod, targetNamespace, podCommand, fd, os.Stdin, os.Stdout, os.Stderr, rec)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// recordingPath returns the path of a new recording in dir, creating dir
+// if necessary.
+func recordingPath(dir, kubeConfig, kubeContext, namespace, pod string) (string, error) {
+	dir, err := homedir.Expand(dir)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("could not create recording directory: %w", err)
+	}
+
+	cluster, err := k8sclient.ContextName(kubeConfig, kubeContext)
+	if err != nil {
+		return "", err
+	}
+
+	return recording.FileName(dir, cluster, namespace, pod, time.Now()), nil
+}
@@ -3,38 +3,20 @@ package exec
 import (
 	"context"
 	"io"
-	"net/url"
 
+	"github.com/glitchcrab/sonar/internal/k8sclient"
+	"github.com/glitchcrab/sonar/internal/recording"
 	"github.com/moby/term"
 	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/kubernetes/scheme"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/remotecommand"
 )
 
-// newExecutor returns an Executor. Websocket is preferred, with SPDY as a fallback.
-func newExecutor(config *restclient.Config, method string, url *url.URL) (remotecommand.Executor, error) {
-	// Try WebSocket first
-	exec, err := remotecommand.NewWebSocketExecutor(config, method, url.String())
-	if err == nil {
-		return exec, nil
-	}
-
-	// Fallback to SPDY
-	return remotecommand.NewSPDYExecutor(config, method, url)
-}
-
-func exec(ctx context.Context, k8sClientSet *kubernetes.Clientset, restClient *restclient.Config, targetPod, targetNamespace string, podCommand []string, fd uintptr, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
-	request := k8sClientSet.CoreV1().
-		RESTClient().
-		Post().
-		Resource("pods").
-		Name(targetPod).
-		Namespace(targetNamespace).
-		SubResource("exec")
-
+// exec runs podCommand in the target pod, attached to the local terminal.
+// If rec is set, the session is also recorded.
+func exec(ctx context.Context, k8sClientSet *kubernetes.Clientset, restClient *restclient.Config, targetPod, targetNamespace string, podCommand []string, fd uintptr, stdin io.Reader, stdout io.Writer, stderr io.Writer, rec *recording.Recorder) error {
 	options := &corev1.PodExecOptions{
 		Command: podCommand,
 		Stdin:   true,
@@ -43,20 +25,28 @@ func exec(ctx context.Context, k8sClientSet *kubernetes.Clientset, restClient *r
 		TTY:     true,
 	}
 
-	request.VersionedParams(
-		options,
-		scheme.ParameterCodec,
-	)
-
-	executor, err := newExecutor(restClient, "POST", request.URL())
+	executor, err := k8sclient.NewExecutor(k8sClientSet, restClient, targetNamespace, targetPod, options)
 	if err != nil {
 		return err
 	}
 
+	// Tee the streams into the recording.
+	if rec != nil {
+		stdin = rec.Input(stdin)
+		stdout = rec.Output(stdout)
+		stderr = rec.Output(stderr)
+	}
+
+	// Stop watching the terminal's size once the session is over.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	streamOpts := remotecommand.StreamOptions{
-		Stdin:  stdin,
-		Stdout: stdout,
-		Stderr: stderr,
+		Stdin:             stdin,
+		Stdout:            stdout,
+		Stderr:            stderr,
+		Tty:               true,
+		TerminalSizeQueue: newSizeQueue(ctx, fd, rec),
 	}
 
 	log.Infof("Connecting to pod %s in namespace %s, use Ctrl+d to exit\n\n", targetPod, targetNamespace)
@@ -65,7 +55,7 @@ func exec(ctx context.Context, k8sClientSet *kubernetes.Clientset, restClient *r
 	var previousState *term.State
 	previousState, err = term.SetRawTerminal(fd)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	// Ensure the terminal is always restored
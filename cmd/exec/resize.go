@@ -0,0 +1,85 @@
+package exec
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/glitchcrab/sonar/internal/recording"
+	"github.com/moby/term"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// sizeQueue passes the local terminal's size on to the remote terminal,
+// recording every change if a recorder is set.
+type sizeQueue struct {
+	ctx   context.Context
+	fd    uintptr
+	rec   *recording.Recorder
+	sizes chan remotecommand.TerminalSize
+}
+
+// newSizeQueue returns a sizeQueue which watches the terminal until ctx is
+// done.
+func newSizeQueue(ctx context.Context, fd uintptr, rec *recording.Recorder) *sizeQueue {
+	q := &sizeQueue{
+		ctx:   ctx,
+		fd:    fd,
+		rec:   rec,
+		sizes: make(chan remotecommand.TerminalSize, 1),
+	}
+
+	// Send the initial size, then every change.
+	q.push(false)
+
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	go func() {
+		defer signal.Stop(winch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-winch:
+				q.push(true)
+			}
+		}
+	}()
+
+	return q
+}
+
+// Next returns the next size of the terminal, or nil once the session is
+// over.
+func (q *sizeQueue) Next() *remotecommand.TerminalSize {
+	select {
+	case size := <-q.sizes:
+		return &size
+	case <-q.ctx.Done():
+		return nil
+	}
+}
+
+// push queues the current size of the terminal, replacing any size which
+// hasn't been sent yet.
+func (q *sizeQueue) push(record bool) {
+	ws, err := term.GetWinsize(q.fd)
+	if err != nil {
+		return
+	}
+
+	if record && q.rec != nil {
+		if err := q.rec.Resize(int(ws.Width), int(ws.Height)); err != nil {
+			log.Warnf("could not record terminal resize: %v", err)
+		}
+	}
+
+	size := remotecommand.TerminalSize{Width: ws.Width, Height: ws.Height}
+	select {
+	case <-q.sizes:
+	default:
+	}
+	q.sizes <- size
+}
@@ -0,0 +1,153 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package gc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/glitchcrab/sonar/cmd/destroy"
+	"github.com/glitchcrab/sonar/internal/app"
+	"github.com/glitchcrab/sonar/internal/config"
+	"github.com/glitchcrab/sonar/internal/fanout"
+	"github.com/glitchcrab/sonar/internal/k8sclient"
+	"github.com/glitchcrab/sonar/internal/logging"
+	"github.com/glitchcrab/sonar/internal/types"
+	"github.com/glitchcrab/sonar/internal/utils"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	dryRun bool
+)
+
+func NewCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "gc",
+		Short: "Deletes Sonar sessions whose TTL has expired",
+		Long: `gc finds all Sonar sessions (deployments, jobs and pods) which were
+created with a TTL (see "sonar create --ttl") and deletes every session
+which has expired.
+
+Sessions which were created without a TTL are never deleted by gc.
+
+Global flags:
+
+Run "sonar help" in order to see flags which apply to all subcommands.
+
+Flags:
+
+--dry-run (default: false)
+
+Lists the expired sessions without deleting them.`,
+		Example: `
+"sonar gc" - deletes all expired sessions across all namespaces.
+
+"sonar gc --all-contexts" - deletes all expired sessions in every
+cluster in the kubeconfig and prints a summary for each cluster.`,
+		RunE: runGCCommand,
+	}
+
+	command.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "list expired sessions without deleting them")
+
+	return command
+}
+
+func runGCCommand(cmd *cobra.Command, args []string) error {
+	// Get the App instance from the command context
+	a, err := app.GetApp(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Search all namespaces unless one was explicitly provided.
+	searchNamespace := a.Globals.Namespace
+	if a.Globals.NamespaceFromContext {
+		searchNamespace = ""
+	}
+
+	// Labels used to match Sonar sessions.
+	searchOpts := metav1.ListOptions{
+		LabelSelector: "owner=sonar",
+	}
+
+	ctx := a.Context
+	now := time.Now()
+
+	results := fanout.Run(ctx, a.Globals.KubeContexts, a.Globals.ClusterTimeout, func(ctx context.Context, kubeContext string) ([]string, error) {
+		// Create a Kubernetes clientset.
+		k8sClientSet, err := k8sclient.New(kubeContext, a.Globals.KubeConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions, err := utils.ListSonarSessions(k8sClientSet, ctx, searchNamespace, searchOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		var errs []error
+		var collected []string
+		for _, s := range sessions {
+			expiresAt, ok := s.Annotations[config.AnnotationExpiresAt]
+			if !ok {
+				continue
+			}
+
+			expiry, err := time.Parse(time.RFC3339, expiresAt)
+			if err != nil {
+				logging.Resource(ctx, s.Namespace, s.Kind, s.Name, types.ActionSkipped).Warnf("%s \"%s/%s\" has an invalid expiry time: %v", s.Kind, s.Namespace, s.Name, err)
+				continue
+			}
+
+			if expiry.After(now) {
+				continue
+			}
+
+			session := fmt.Sprintf("%s/%s", s.Namespace, s.Name)
+			if dryRun {
+				collected = append(collected, session)
+				continue
+			}
+
+			// Match every resource which belongs to the session.
+			opts := config.NewDeleteConfig(s.Namespace, s.Name, s.Labels)
+
+			if _, err := destroy.Resources(k8sClientSet, ctx, opts, true); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			collected = append(collected, session)
+		}
+
+		return collected, errors.Join(errs...)
+	})
+
+	return fanout.Summarise(os.Stdout, results, func(sessions []string) string {
+		if len(sessions) == 0 {
+			return "no expired sessions"
+		}
+		if dryRun {
+			return fmt.Sprintf("expired: %s", strings.Join(sessions, ", "))
+		}
+		return fmt.Sprintf("deleted: %s", strings.Join(sessions, ", "))
+	})
+}
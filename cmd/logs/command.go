@@ -0,0 +1,213 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logs
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/glitchcrab/sonar/internal/app"
+	"github.com/glitchcrab/sonar/internal/k8sclient"
+	"github.com/glitchcrab/sonar/internal/types"
+	"github.com/glitchcrab/sonar/internal/utils"
+	"github.com/moby/term"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var (
+	all      bool
+	follow   bool
+	previous bool
+	selector string
+	since    time.Duration
+	tail     int64
+)
+
+func NewCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "logs",
+		Short: "Prints the logs of Sonar debug containers",
+		Long: `Logs prints the output of the debug container in one or more Sonar
+pods, using the current (or provided) kubectl context. It searches for
+pods with the label 'owner=sonar' in the same way as exec and prompts
+the user to select a pod. Pods of every kind of session (deployment,
+job or pod) are matched, whether or not they are still running.
+
+If --name, --selector or --all matches more than one pod, the logs of
+every matching pod are printed together, with each line prefixed by
+the pod it came from (in colour, when writing to a terminal).
+
+Global flags:
+
+Run "sonar help" in order to see flags which apply to all subcommands.
+
+Flags:
+
+--all (default: false)
+
+Prints the logs of every matching pod without prompting for a
+selection.
+
+--follow/-f (default: false)
+
+Streams new log lines until interrupted (or until every container has
+exited).
+
+--previous/-p (default: false)
+
+Prints the logs of the previous instance of the container, if it was
+restarted.
+
+--selector/-l (default: none)
+
+Only matches pods whose labels match the provided label selector (e.g.
+'team=network').
+
+--since (default: none)
+
+Only prints lines newer than the provided duration (e.g. '10m').
+
+--tail (default: all lines)
+
+Number of recent lines to print.`,
+		Example: `
+"sonar logs" - prompts the user to select a Sonar pod and prints its
+logs.
+
+"sonar logs --name diag --follow" - streams the logs of the 'sonar-diag'
+session as they are written.
+
+"sonar logs --all --since 10m --namespace kube-system" - prints the last
+ten minutes of logs from every Sonar pod in namespace 'kube-system'.`,
+		RunE: runLogsCommand,
+	}
+
+	command.Flags().BoolVar(&all, "all", false, "print the logs of all matching pods without prompting for a selection")
+	command.Flags().BoolVarP(&follow, "follow", "f", false, "stream new log lines")
+	command.Flags().BoolVarP(&previous, "previous", "p", false, "print the logs of the previous container instance")
+	command.Flags().StringVarP(&selector, "selector", "l", "", "only match pods matching the label selector")
+	command.Flags().DurationVar(&since, "since", 0, "only print lines newer than the provided duration (e.g. 10m)")
+	command.Flags().Int64Var(&tail, "tail", -1, "number of recent lines to print (default: all lines)")
+
+	return command
+}
+
+func runLogsCommand(cmd *cobra.Command, args []string) error {
+	// Get the App instance from the command context
+	a, err := app.GetApp(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := a.Globals.RequireSingleContext("logs"); err != nil {
+		return err
+	}
+
+	v, err := app.GetViper(cmd)
+	if err != nil {
+		return err
+	}
+
+	if since < 0 {
+		return fmt.Errorf("--since must not be negative")
+	}
+
+	// Create a Kubernetes clientset.
+	k8sClientSet, err := k8sclient.New(a.Globals.KubeContext, a.Globals.KubeConfig)
+	if err != nil {
+		return err
+	}
+
+	// Labels used to match Sonar containers.
+	searchLabels := []string{"owner=sonar"}
+
+	// Only match the named session if a name was provided.
+	nameProvided := v.IsSet("name")
+	if nameProvided {
+		searchLabels = append(searchLabels, fmt.Sprintf("name=%s", a.Globals.Name))
+	}
+
+	// Add any user-provided selector.
+	if selector != "" {
+		searchLabels = append(searchLabels, selector)
+	}
+
+	// Get all pods matching the search labels.
+	ctx := a.Context
+	discoveredPods, err := utils.FindSonarPods(k8sClientSet, ctx, a.Globals.Name, a.Globals.Namespace, searchLabels)
+	if err != nil {
+		return err
+	}
+
+	// Print the logs of every matching pod if the user asked for a
+	// specific set of pods, otherwise prompt them to select one.
+	selected := discoveredPods
+	if !all && !nameProvided && selector == "" && len(discoveredPods) > 1 {
+		selected, err = selectPod(discoveredPods)
+		if err != nil {
+			return err
+		}
+	}
+
+	opts := &corev1.PodLogOptions{
+		Container: "sonar",
+		Follow:    follow,
+		Previous:  previous,
+	}
+	if tail >= 0 {
+		opts.TailLines = &tail
+	}
+	if since > 0 {
+		seconds := int64(since.Seconds())
+		opts.SinceSeconds = &seconds
+	}
+
+	// Only prefix lines when they come from several pods.
+	if len(selected) == 1 {
+		return streamLogs(ctx, k8sClientSet, selected[0], opts, os.Stdout)
+	}
+
+	log.Infof("printing logs of %d pods", len(selected))
+
+	return multiplexLogs(ctx, k8sClientSet, selected, opts, os.Stdout, term.IsTerminal(os.Stdout.Fd()))
+}
+
+// selectPod prompts the user to select one of the pods.
+func selectPod(pods []types.DiscoveredPod) ([]types.DiscoveredPod, error) {
+	var podList []string
+	for _, pod := range pods {
+		podList = append(podList, fmt.Sprintf("%s/%s", pod.Namespace, pod.Name))
+	}
+
+	// Prompt the user to select which pod to print the logs of.
+	selectedPod, err := utils.DisplaySelectionPrompt("Select pod to print the logs of", podList)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, name, _ := strings.Cut(selectedPod, "/")
+	for _, pod := range pods {
+		if pod.Namespace == namespace && pod.Name == name {
+			return []types.DiscoveredPod{pod}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("pod %s not found", selectedPod)
+}
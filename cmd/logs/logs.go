@@ -0,0 +1,67 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/glitchcrab/sonar/internal/types"
+	"github.com/glitchcrab/sonar/internal/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// streamLogs copies the logs of a single pod to w.
+func streamLogs(ctx context.Context, k8sClientSet *kubernetes.Clientset, pod types.DiscoveredPod, opts *corev1.PodLogOptions, w io.Writer) error {
+	stream, err := k8sClientSet.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts).Stream(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get logs of pod \"%s/%s\": %w", pod.Namespace, pod.Name, err)
+	}
+	defer stream.Close()
+
+	if _, err := io.Copy(w, stream); err != nil {
+		return fmt.Errorf("could not get logs of pod \"%s/%s\": %w", pod.Namespace, pod.Name, err)
+	}
+
+	return nil
+}
+
+// multiplexLogs streams the logs of every pod concurrently, writing each
+// line to w prefixed with the pod it came from. Prefixes are coloured if
+// colour is set.
+func multiplexLogs(ctx context.Context, k8sClientSet *kubernetes.Clientset, pods []types.DiscoveredPod, opts *corev1.PodLogOptions, w io.Writer, colour bool) error {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := make([]error, len(pods))
+
+	for i, pod := range pods {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			pw := utils.NewPrefixWriter(w, &mu, fmt.Sprintf("%s/%s", pod.Namespace, pod.Name), i, colour)
+			errs[i] = errors.Join(streamLogs(ctx, k8sClientSet, pod, opts, pw), pw.Flush())
+		}()
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
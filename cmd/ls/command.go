@@ -17,16 +17,27 @@ package ls
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/glitchcrab/sonar/internal/app"
+	"github.com/glitchcrab/sonar/internal/config"
+	"github.com/glitchcrab/sonar/internal/fanout"
 	"github.com/glitchcrab/sonar/internal/k8sclient"
 	"github.com/glitchcrab/sonar/internal/types"
+	"github.com/glitchcrab/sonar/internal/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+var (
+	output string
+)
+
 func NewCommand() *cobra.Command {
 	command := &cobra.Command{
 		Use:     "ls",
@@ -34,16 +45,38 @@ func NewCommand() *cobra.Command {
 		Short:   "Lists all Sonar debug containers",
 		Long: `ls attempts to discover all debug containers in the cluster
 which were created by Sonar. It searches for pods with the label
-'owner=sonar' and lists them for the user.
+'owner=sonar' and lists them for the user, along with the kind of
+session (deployment, job or pod) which they belong to. Sessions which
+run as a borrowed ServiceAccount (see "sonar create --service-account")
+are flagged as such.
 
 Global flags:
 
-Run "sonar help" in order to see flags which apply to all subcommands.`,
+Run "sonar help" in order to see flags which apply to all subcommands.
+
+Flags:
+
+--output/-o (default: none)
+
+Output format. 'wide' adds the node, the creating user, the creation
+time, the Sonar version, the reason and the effective options of each
+session. 'tree' shows every resource of each session, nested under
+the resource which owns it.`,
 		Example: `
-"sonar ls" - finds all Sonar pods across all namespaces.`,
+"sonar ls" - finds all Sonar pods across all namespaces.
+
+"sonar ls -o wide" - also shows who created each session, when, why
+and with which options.
+
+"sonar ls -o tree" - shows the resources which make up each session.
+
+"sonar ls --context 'prod-*'" - finds all Sonar pods in every cluster
+whose context matches 'prod-*', adding a cluster column to the output.`,
 		RunE: runLsCommand,
 	}
 
+	command.Flags().StringVarP(&output, "output", "o", "", "output format (wide|tree)")
+
 	return command
 }
 
@@ -54,11 +87,10 @@ func runLsCommand(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Create a Kubernetes clientset.
-	k8sClientSet, err := k8sclient.New(a.Globals.KubeContext, a.Globals.KubeConfig)
-	if err != nil {
-		return err
+	if output != "" && output != "wide" && output != "tree" {
+		return fmt.Errorf("unsupported output format %q", output)
 	}
+	wide := output == "wide"
 
 	// Assemble lookup options
 
@@ -70,33 +102,154 @@ func runLsCommand(cmd *cobra.Command, args []string) error {
 		LabelSelector: strings.Join(searchLabels, ","),
 	}
 
-	// Get all pods in the cluster matching the search options.
-	ctx := context.TODO()
-	pods, err := k8sClientSet.CoreV1().Pods("").List(ctx, searchOpts)
-	if err != nil {
-		return err
+	ctx := a.Context
+	if output == "tree" {
+		return runTree(a, searchOpts)
 	}
 
-	// Add all discovered pods to the list of discovered pods.
+	// Get all pods in each cluster matching the search options.
+	results := fanout.Run(ctx, a.Globals.KubeContexts, a.Globals.ClusterTimeout, func(ctx context.Context, kubeContext string) ([]types.DiscoveredPod, error) {
+		// Create a Kubernetes clientset.
+		k8sClientSet, err := k8sclient.New(kubeContext, a.Globals.KubeConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		pods, err := k8sClientSet.CoreV1().Pods("").List(ctx, searchOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		// Add all discovered pods to the list of discovered pods.
+		discoveredPods := []types.DiscoveredPod{}
+		for _, pod := range pods.Items {
+			discoveredPods = append(discoveredPods, types.DiscoveredPod{
+				Annotations:    pod.Annotations,
+				Context:        kubeContext,
+				Kind:           utils.SessionKind(&pod),
+				Name:           pod.Name,
+				Namespace:      pod.Namespace,
+				NodeName:       pod.Spec.NodeName,
+				ServiceAccount: pod.Spec.ServiceAccountName,
+				Status:         pod.Status.Phase,
+			})
+		}
+
+		return discoveredPods, nil
+	})
+
+	// Aggregate the pods from all clusters, collecting any errors.
+	var errs []error
 	discoveredPods := []types.DiscoveredPod{}
-	for _, pod := range pods.Items {
-		discoveredPods = append(discoveredPods, types.DiscoveredPod{
-			Name:      pod.Name,
-			Namespace: pod.Namespace,
-			Status:    pod.Status.Phase,
-		})
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("context %q: %w", result.Context, result.Err))
+			continue
+		}
+		discoveredPods = append(discoveredPods, result.Value...)
 	}
 
 	// Raise a clean exit if no pods found.
 	if len(discoveredPods) == 0 {
+		if len(errs) > 0 {
+			return errors.Join(errs...)
+		}
 		log.Infof("no pods found with labels %s across all namespaces", strings.Join(searchLabels, ","))
 		return nil
 	}
 
+	// Only show the cluster column when operating on multiple clusters.
+	multiCluster := len(a.Globals.KubeContexts) > 1
+
 	// Print all discovered pods.
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	if multiCluster {
+		fmt.Fprint(w, "CLUSTER\t")
+	}
+	fmt.Fprint(w, "NAMESPACE\tNAME\tKIND\tSTATUS\tSERVICEACCOUNT")
+	if wide {
+		fmt.Fprint(w, "\tNODE\tUSER\tCREATED\tVERSION\tREASON\tOPTIONS")
+	}
+	fmt.Fprintln(w)
+
 	for _, pod := range discoveredPods {
-		log.Infof("found %s in namespace %s (status: %s)", pod.Name, pod.Namespace, pod.Status)
+		if multiCluster {
+			fmt.Fprintf(w, "%s\t", pod.Context)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s", pod.Namespace, pod.Name, pod.Kind, pod.Status, serviceAccount(pod))
+		if wide {
+			fmt.Fprintf(w, "\t%s\t%s\t%s\t%s\t%s\t%s",
+				valueOrNone(pod.NodeName),
+				valueOrNone(pod.Annotations[config.AnnotationUser]),
+				valueOrNone(pod.Annotations[config.AnnotationCreatedAt]),
+				valueOrNone(pod.Annotations[config.AnnotationVersion]),
+				valueOrNone(pod.Annotations[config.AnnotationReason]),
+				valueOrNone(pod.Annotations[config.AnnotationOptions]),
+			)
+		}
+		fmt.Fprintln(w)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	return errors.Join(errs...)
+}
+
+// runTree prints the resource tree of every session in each cluster.
+func runTree(a *app.App, searchOpts metav1.ListOptions) error {
+	results := fanout.Run(a.Context, a.Globals.KubeContexts, a.Globals.ClusterTimeout, func(ctx context.Context, kubeContext string) ([]*treeNode, error) {
+		// Create a Kubernetes clientset.
+		k8sClientSet, err := k8sclient.New(kubeContext, a.Globals.KubeConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		return buildTrees(k8sClientSet, ctx, searchOpts)
+	})
+
+	// Only show the cluster headings when operating on multiple clusters.
+	multiCluster := len(a.Globals.KubeContexts) > 1
+
+	var errs []error
+	var found int
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("context %q: %w", result.Context, result.Err))
+			continue
+		}
+		if len(result.Value) == 0 {
+			continue
+		}
+
+		if multiCluster {
+			fmt.Fprintf(os.Stdout, "CLUSTER %s\n", result.Context)
+		}
+		printTrees(os.Stdout, result.Value)
+		found += len(result.Value)
+	}
+
+	if found == 0 && len(errs) == 0 {
+		log.Infof("no resources found with labels %s across all namespaces", searchOpts.LabelSelector)
+	}
+
+	return errors.Join(errs...)
+}
+
+// serviceAccount returns the pod's ServiceAccount, flagging identities
+// which were borrowed rather than created by Sonar.
+func serviceAccount(pod types.DiscoveredPod) string {
+	if _, ok := pod.Annotations[config.AnnotationBorrowedServiceAccount]; ok {
+		return pod.ServiceAccount + " (borrowed)"
 	}
 
-	return nil
+	return valueOrNone(pod.ServiceAccount)
+}
+
+// valueOrNone returns a placeholder for empty table values.
+func valueOrNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
 }
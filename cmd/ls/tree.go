@@ -0,0 +1,319 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package ls
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// treeNode is a single resource in a session's resource tree.
+type treeNode struct {
+	children  []*treeNode
+	kind      string
+	name      string
+	namespace string
+	owners    []k8stypes.UID
+	status    string
+	uid       k8stypes.UID
+}
+
+// treeLister lists every resource of one kind which matches listOpts.
+type treeLister func(k8sClientSet *kubernetes.Clientset, ctx context.Context, listOpts metav1.ListOptions) ([]*treeNode, error)
+
+// treeListers lists every kind of resource which appears in a session's
+// resource tree.
+var treeListers = []treeLister{
+	listConfigMapNodes,
+	listDeploymentNodes,
+	listJobNodes,
+	listReplicaSetNodes,
+	listPodNodes,
+	listServiceAccountNodes,
+	listNetworkPolicyNodes,
+	listRoleNodes,
+	listRoleBindingNodes,
+	listSecretNodes,
+}
+
+// buildTrees lists every Sonar resource in the cluster and links them
+// through their ownerReferences. Resources without a Sonar owner (usually
+// a session's anchor) are returned as roots.
+func buildTrees(k8sClientSet *kubernetes.Clientset, ctx context.Context, listOpts metav1.ListOptions) ([]*treeNode, error) {
+	var nodes []*treeNode
+	for _, list := range treeListers {
+		n, err := list(k8sClientSet, ctx, listOpts)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n...)
+	}
+
+	byUID := make(map[k8stypes.UID]*treeNode, len(nodes))
+	for _, n := range nodes {
+		byUID[n.uid] = n
+	}
+
+	var roots []*treeNode
+	for _, n := range nodes {
+		linked := false
+		for _, uid := range n.owners {
+			if owner, ok := byUID[uid]; ok {
+				owner.children = append(owner.children, n)
+				linked = true
+				break
+			}
+		}
+		if !linked {
+			roots = append(roots, n)
+		}
+	}
+
+	sortNodes(roots)
+
+	return roots, nil
+}
+
+// sortNodes orders nodes, and recursively their children, by namespace,
+// kind and name.
+func sortNodes(nodes []*treeNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		if a.namespace != b.namespace {
+			return a.namespace < b.namespace
+		}
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+		return a.name < b.name
+	})
+
+	for _, n := range nodes {
+		sortNodes(n.children)
+	}
+}
+
+// printTrees writes each root and its descendants to w.
+func printTrees(w io.Writer, roots []*treeNode) {
+	for _, root := range roots {
+		fmt.Fprintf(w, "%s/%s\n", root.namespace, root.label())
+		printChildren(w, root.children, "")
+	}
+}
+
+func printChildren(w io.Writer, children []*treeNode, prefix string) {
+	for i, child := range children {
+		branch, indent := "├── ", "│   "
+		if i == len(children)-1 {
+			branch, indent = "└── ", "    "
+		}
+
+		fmt.Fprintf(w, "%s%s%s\n", prefix, branch, child.label())
+		printChildren(w, child.children, prefix+indent)
+	}
+}
+
+// label returns the node in kind/name form, followed by its status if it
+// has one.
+func (n *treeNode) label() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s/%s", n.kind, n.name)
+	if n.status != "" {
+		fmt.Fprintf(&b, " (%s)", n.status)
+	}
+
+	return b.String()
+}
+
+// newTreeNode returns a node for the object described by meta.
+func newTreeNode(kind string, meta metav1.ObjectMeta, status string) *treeNode {
+	n := &treeNode{
+		kind:      kind,
+		name:      meta.Name,
+		namespace: meta.Namespace,
+		status:    status,
+		uid:       meta.UID,
+	}
+	for _, ref := range meta.OwnerReferences {
+		n.owners = append(n.owners, ref.UID)
+	}
+
+	return n
+}
+
+func listConfigMapNodes(k8sClientSet *kubernetes.Clientset, ctx context.Context, listOpts metav1.ListOptions) ([]*treeNode, error) {
+	cms, err := k8sClientSet.CoreV1().ConfigMaps("").List(ctx, listOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []*treeNode
+	for _, cm := range cms.Items {
+		nodes = append(nodes, newTreeNode("configmap", cm.ObjectMeta, ""))
+	}
+
+	return nodes, nil
+}
+
+func listDeploymentNodes(k8sClientSet *kubernetes.Clientset, ctx context.Context, listOpts metav1.ListOptions) ([]*treeNode, error) {
+	deployments, err := k8sClientSet.AppsV1().Deployments("").List(ctx, listOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []*treeNode
+	for _, d := range deployments.Items {
+		var replicas int32 = 1
+		if d.Spec.Replicas != nil {
+			replicas = *d.Spec.Replicas
+		}
+		status := fmt.Sprintf("%d/%d ready", d.Status.ReadyReplicas, replicas)
+		nodes = append(nodes, newTreeNode("deployment", d.ObjectMeta, status))
+	}
+
+	return nodes, nil
+}
+
+func listJobNodes(k8sClientSet *kubernetes.Clientset, ctx context.Context, listOpts metav1.ListOptions) ([]*treeNode, error) {
+	jobs, err := k8sClientSet.BatchV1().Jobs("").List(ctx, listOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []*treeNode
+	for _, j := range jobs.Items {
+		status := "running"
+		switch {
+		case j.Status.Succeeded > 0:
+			status = "succeeded"
+		case j.Status.Failed > 0:
+			status = "failed"
+		}
+		nodes = append(nodes, newTreeNode("job", j.ObjectMeta, status))
+	}
+
+	return nodes, nil
+}
+
+func listReplicaSetNodes(k8sClientSet *kubernetes.Clientset, ctx context.Context, listOpts metav1.ListOptions) ([]*treeNode, error) {
+	replicaSets, err := k8sClientSet.AppsV1().ReplicaSets("").List(ctx, listOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []*treeNode
+	for _, rs := range replicaSets.Items {
+		// Skip old ReplicaSets which have been scaled down.
+		if rs.Spec.Replicas != nil && *rs.Spec.Replicas == 0 {
+			continue
+		}
+		nodes = append(nodes, newTreeNode("replicaset", rs.ObjectMeta, ""))
+	}
+
+	return nodes, nil
+}
+
+func listPodNodes(k8sClientSet *kubernetes.Clientset, ctx context.Context, listOpts metav1.ListOptions) ([]*treeNode, error) {
+	pods, err := k8sClientSet.CoreV1().Pods("").List(ctx, listOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []*treeNode
+	for _, pod := range pods.Items {
+		nodes = append(nodes, newTreeNode("pod", pod.ObjectMeta, string(pod.Status.Phase)))
+	}
+
+	return nodes, nil
+}
+
+func listServiceAccountNodes(k8sClientSet *kubernetes.Clientset, ctx context.Context, listOpts metav1.ListOptions) ([]*treeNode, error) {
+	sas, err := k8sClientSet.CoreV1().ServiceAccounts("").List(ctx, listOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []*treeNode
+	for _, sa := range sas.Items {
+		nodes = append(nodes, newTreeNode("serviceaccount", sa.ObjectMeta, ""))
+	}
+
+	return nodes, nil
+}
+
+func listNetworkPolicyNodes(k8sClientSet *kubernetes.Clientset, ctx context.Context, listOpts metav1.ListOptions) ([]*treeNode, error) {
+	nps, err := k8sClientSet.NetworkingV1().NetworkPolicies("").List(ctx, listOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []*treeNode
+	for _, np := range nps.Items {
+		nodes = append(nodes, newTreeNode("networkpolicy", np.ObjectMeta, ""))
+	}
+
+	return nodes, nil
+}
+
+func listRoleNodes(k8sClientSet *kubernetes.Clientset, ctx context.Context, listOpts metav1.ListOptions) ([]*treeNode, error) {
+	roles, err := k8sClientSet.RbacV1().Roles("").List(ctx, listOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []*treeNode
+	for _, role := range roles.Items {
+		nodes = append(nodes, newTreeNode("role", role.ObjectMeta, ""))
+	}
+
+	return nodes, nil
+}
+
+func listRoleBindingNodes(k8sClientSet *kubernetes.Clientset, ctx context.Context, listOpts metav1.ListOptions) ([]*treeNode, error) {
+	bindings, err := k8sClientSet.RbacV1().RoleBindings("").List(ctx, listOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []*treeNode
+	for _, rb := range bindings.Items {
+		status := fmt.Sprintf("%s %s", strings.ToLower(rb.RoleRef.Kind), rb.RoleRef.Name)
+		nodes = append(nodes, newTreeNode("rolebinding", rb.ObjectMeta, status))
+	}
+
+	return nodes, nil
+}
+
+func listSecretNodes(k8sClientSet *kubernetes.Clientset, ctx context.Context, listOpts metav1.ListOptions) ([]*treeNode, error) {
+	secrets, err := k8sClientSet.CoreV1().Secrets("").List(ctx, listOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []*treeNode
+	for _, secret := range secrets.Items {
+		nodes = append(nodes, newTreeNode("secret", secret.ObjectMeta, ""))
+	}
+
+	return nodes, nil
+}
@@ -17,28 +17,65 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/glitchcrab/sonar/cmd/apply"
+	"github.com/glitchcrab/sonar/cmd/capture"
 	"github.com/glitchcrab/sonar/cmd/configfile"
 	"github.com/glitchcrab/sonar/cmd/create"
 	"github.com/glitchcrab/sonar/cmd/destroy"
 	"github.com/glitchcrab/sonar/cmd/exec"
+	"github.com/glitchcrab/sonar/cmd/gc"
+	"github.com/glitchcrab/sonar/cmd/logs"
 	"github.com/glitchcrab/sonar/cmd/ls"
+	"github.com/glitchcrab/sonar/cmd/run"
+	"github.com/glitchcrab/sonar/cmd/status"
+	"github.com/glitchcrab/sonar/cmd/toolkits"
 	"github.com/glitchcrab/sonar/cmd/version"
 	"github.com/glitchcrab/sonar/internal/app"
+	"github.com/glitchcrab/sonar/internal/completion"
 	"github.com/glitchcrab/sonar/internal/config"
+	"github.com/glitchcrab/sonar/internal/k8sclient"
+	"github.com/glitchcrab/sonar/internal/logging"
+	"github.com/glitchcrab/sonar/internal/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+// Exit codes returned by Sonar.
+const (
+	// ExitOK is returned when the command succeeded.
+	ExitOK = 0
+	// ExitError is returned for all errors without a more specific code.
+	ExitError = 1
+	// ExitNoSessions is returned when no Sonar sessions matched.
+	ExitNoSessions = 3
+	// ExitAborted is returned when the user cancelled a prompt.
+	ExitAborted = 130
+)
+
 var (
-	configFile  string
-	kubeConfig  string
-	kubeContext string
-	name        string
-	namespace   string
-	v           *viper.Viper
+	allContexts    bool
+	clusterTimeout time.Duration
+	configFile     string
+	kubeConfig     string
+	kubeContexts   []string
+	logFormat      string
+	logLevel       string
+	name           string
+	namespace      string
+	quiet          bool
+	timeout        time.Duration
+	v              *viper.Viper
+
+	// cancelTimeout releases the resources of the global timeout.
+	cancelTimeout context.CancelFunc = func() {}
 )
 
 func NewRootCommand() *cobra.Command {
@@ -61,7 +98,21 @@ If that is not set, Sonar will use the default kubeconfig file location.
 
 --context (default: current context in kube config)
 
-Name of the kubernetes context to use.
+Name of the kubernetes context to use. May be provided multiple times
+(or as a comma-separated list) and may contain glob patterns, such as
+'prod-*'. '*' also matches '/', so '*prod*' matches EKS contexts such
+as 'arn:aws:eks:eu-west-1:123456789012:cluster/prod'. Commands which
+support multiple clusters (ls, destroy, gc) run against every matching
+context concurrently; other commands require exactly one context.
+
+--all-contexts (default: false)
+
+Run against every context in the kubeconfig.
+
+--cluster-timeout (default: 30s)
+
+Maximum time to spend on each cluster when running against multiple
+contexts.
 
 --name
 
@@ -73,8 +124,48 @@ provided then resource names will start with 'sonar-'.
 
 --namespace (default: 'default')
 
-Namespace to deploy resources to.`,
+Namespace to deploy resources to.
+
+--timeout (default: none)
+
+Maximum time for the whole command to run (e.g. '2m'). Interrupting
+Sonar (Ctrl-C) or exceeding the timeout cancels all in-flight API
+calls, watches and exec streams.
+
+--log-level (default: 'info')
+
+Level of the diagnostic logs (debug, info, warn or error). Logs are
+written to stderr, while results are written to stdout.
+
+--log-format (default: 'text')
+
+Format of the diagnostic logs, 'text' or 'json'. Every entry carries
+the cluster and namespace which it relates to and, where relevant, the
+resource and the action taken on it.
+
+--quiet/-q (default: false)
+
+Only logs errors. Cannot be combined with --log-level.
+
+Exit codes:
+
+0   - success
+1   - error
+3   - no Sonar sessions matched (e.g. nothing to exec into or delete)
+130 - a prompt was cancelled (Ctrl-C, Ctrl-D or an empty selection) or
+      Sonar was interrupted`,
+		SilenceErrors: true,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Flags have been parsed successfully by now, so don't print
+			// the usage text for any subsequent errors.
+			cmd.SilenceUsage = true
+
+			// Configure logging before anything is logged.
+			levelSet := cmd.Flags().Changed("log-level")
+			if err := logging.Configure(log.StandardLogger(), os.Stderr, logLevel, logFormat, quiet, levelSet); err != nil {
+				return err
+			}
+
 			// Validate user-prvided config.
 			err := initRootConfig(cmd, args)
 			if err != nil {
@@ -88,16 +179,42 @@ Namespace to deploy resources to.`,
 	// Add global flags
 	root.PersistentFlags().StringVar(&configFile, "config", "", "path to Sonar config file")
 	root.PersistentFlags().StringVar(&kubeConfig, "kubeconfig", "", "absolute path to kubeconfig file (default: '/home/$user/.kube/config')")
-	root.PersistentFlags().StringVar(&kubeContext, "context", "", "context to use")
+	root.PersistentFlags().StringSliceVar(&kubeContexts, "context", nil, "context(s) to use; accepts glob patterns")
+	root.PersistentFlags().BoolVar(&allContexts, "all-contexts", false, "use all contexts in the kubeconfig")
+	root.PersistentFlags().DurationVar(&clusterTimeout, "cluster-timeout", 30*time.Second, "timeout for each cluster when using multiple contexts")
 	root.PersistentFlags().StringVarP(&name, "name", "N", "", "resource name (max 50 characters) (automatically prepended with 'sonar-')")
 	root.PersistentFlags().StringVarP(&namespace, "namespace", "n", "", "namespace to operate in")
+	root.PersistentFlags().DurationVar(&timeout, "timeout", 0, "maximum time for the command to run (e.g. 2m)")
+	root.PersistentFlags().StringVar(&logLevel, "log-level", "info", "level of diagnostic logs (debug|info|warn|error)")
+	root.PersistentFlags().StringVar(&logFormat, "log-format", logging.FormatText, "format of diagnostic logs (text|json)")
+	root.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "only log errors")
+
+	// Add completions for global flags
+	for flag, fn := range map[string]completion.Func{
+		"context":    completion.Contexts,
+		"log-format": completion.Values(logging.FormatText, logging.FormatJSON),
+		"log-level":  completion.Values("debug", "info", "warn", "error"),
+		"name":       completion.Names,
+		"namespace":  completion.Namespaces,
+	} {
+		if err := root.RegisterFlagCompletionFunc(flag, fn); err != nil {
+			log.Fatalf("failed to register completion for --%s: %v", flag, err)
+		}
+	}
 
 	// Add subcommands
 	root.AddCommand(
+		apply.NewCommand(),
+		capture.NewCommand(),
 		create.NewCommand(),
 		destroy.NewCommand(),
 		exec.NewCommand(),
+		gc.NewCommand(),
+		logs.NewCommand(),
 		ls.NewCommand(),
+		run.NewCommand(),
+		status.NewCommand(),
+		toolkits.NewCommand(),
 		configfile.NewCommand(),
 		version.NewCommand(),
 	)
@@ -105,10 +222,69 @@ Namespace to deploy resources to.`,
 	return root
 }
 
+// Execute runs the root command and returns the exit code for the process.
+func Execute() int {
+	// Cancel the root context on the first interrupt. Signal handling is
+	// then stopped so that a second interrupt terminates Sonar immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	defer stop()
+
+	err := NewRootCommand().ExecuteContext(ctx)
+	cancelTimeout()
+
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, utils.ErrNoSessions):
+		log.Info(err)
+		return ExitNoSessions
+	case errors.Is(err, utils.ErrPromptAborted):
+		log.Info("aborted")
+		return ExitAborted
+	case errors.Is(err, context.Canceled):
+		reportError("Interrupted", err)
+		return ExitAborted
+	default:
+		reportError("Error", err)
+		return ExitError
+	}
+}
+
+// reportError writes the error which ended the command to stderr. It is
+// logged as an entry instead when logs are written as JSON, so that they
+// can be parsed.
+func reportError(prefix string, err error) {
+	if logging.JSON(log.StandardLogger()) {
+		log.Error(err)
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: %v\n", prefix, err)
+}
+
+// isCompletion reports whether cmd generates completion scripts or
+// serves completion requests from the shell.
+func isCompletion(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		switch c.Name() {
+		case "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+			return true
+		}
+	}
+
+	return false
+}
+
 func initRootConfig(root *cobra.Command, args []string) error {
 	// Skip config initialisation for commands which do not need it.
-	if root.Annotations["skip-init-config"] == "true" {
-		log.Info("skipping config initialisation")
+	// Shell completion must work without a valid kubeconfig; completion
+	// handlers read the flags themselves.
+	if root.Annotations["skip-init-config"] == "true" || isCompletion(root) {
+		log.Debug("skipping config initialisation")
 		return nil
 	}
 
@@ -116,16 +292,28 @@ func initRootConfig(root *cobra.Command, args []string) error {
 	var err error
 	v, err = initViperConfig(root)
 	if err != nil {
-		log.Fatalf("error initialising config: %v", err)
+		return fmt.Errorf("error initialising config: %w", err)
+	}
+
+	// Resolve the requested contexts. An empty context refers to the
+	// current context in the kubeconfig.
+	contexts := []string{""}
+	if len(kubeContexts) > 0 || allContexts {
+		contexts, err = k8sclient.ResolveContexts(kubeConfig, kubeContexts, allContexts)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Create config struct.
 	globals := config.Globals{
-		KubeConfig:  kubeConfig,
-		KubeContext: kubeContext,
-		Labels:      make(map[string]string),
-		Name:        v.GetString("name"),
-		Namespace:   v.GetString("namespace"),
+		ClusterTimeout: clusterTimeout,
+		KubeConfig:     kubeConfig,
+		KubeContext:    contexts[0],
+		KubeContexts:   contexts,
+		Labels:         make(map[string]string),
+		Name:           v.GetString("name"),
+		Namespace:      v.GetString("namespace"),
 	}
 
 	// Validate user-provided config values.
@@ -133,13 +321,34 @@ func initRootConfig(root *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Attribute logs to the cluster and namespace being operated on.
+	// Commands which run against several clusters attribute each entry
+	// to its own cluster.
+	cluster := ""
+	if len(contexts) == 1 {
+		cluster, err = k8sclient.ContextName(kubeConfig, contexts[0])
+		if err != nil {
+			log.Debugf("could not establish the current context: %v", err)
+		}
+	}
+	logging.SetDefaults(cluster, globals.Namespace)
+
+	// Apply the global timeout to the root context.
+	ctx := root.Context()
+	if timeout > 0 {
+		ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+	}
+
 	// Instantiate an App struct.
-	app := &app.App{Globals: globals}
+	app := &app.App{
+		Context: ctx,
+		Globals: globals,
+	}
 
 	appKey := app.RetrieveAppKey()
 
 	// Add the App struct to the command's context.
-	ctx := context.WithValue(root.Context(), appKey, app)
+	ctx = context.WithValue(ctx, appKey, app)
 
 	// Add the Viper instance to the command's context.
 	viperKey := app.RetrieveViperKey()
@@ -163,7 +372,7 @@ func initViperConfig(cmd *cobra.Command) (*viper.Viper, error) {
 		// Search for the config file in the user's home directory.
 		configFilePath, _ = config.FindConfigFile()
 		if configFilePath == "" {
-			log.Infof("config file not found")
+			log.Debug("config file not found")
 		}
 	}
 
@@ -177,18 +386,16 @@ func initViperConfig(cmd *cobra.Command) (*viper.Viper, error) {
 				return nil, err
 			}
 		} else {
-			log.Infof("using config file: %s", v.ConfigFileUsed())
+			log.Debugf("using config file: %s", v.ConfigFileUsed())
 		}
 	}
 
 	// Bind some flags to Viper.
 	if err := v.BindPFlag("name", cmd.Root().PersistentFlags().Lookup("name")); err != nil {
-		fmt.Println("error binding name flag to viper")
-		return nil, err
+		return nil, fmt.Errorf("error binding name flag to viper: %w", err)
 	}
 	if err := v.BindPFlag("namespace", cmd.Root().PersistentFlags().Lookup("namespace")); err != nil {
-		fmt.Println("error binding namespace flag to viper")
-		return nil, err
+		return nil, fmt.Errorf("error binding namespace flag to viper: %w", err)
 	}
 
 	return v, nil
@@ -0,0 +1,210 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package run
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/glitchcrab/sonar/internal/app"
+	"github.com/glitchcrab/sonar/internal/k8sclient"
+	"github.com/glitchcrab/sonar/internal/types"
+	"github.com/glitchcrab/sonar/internal/utils"
+	"github.com/moby/term"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var (
+	all      bool
+	script   string
+	selector string
+	shell    string
+)
+
+func NewCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "run --script <file> [args...]",
+		Short: "Runs a local script in Sonar debug containers",
+		Long: `Run streams a local script over the exec stdin to a shell in one or
+more running Sonar pods, and prints its output. The script doesn't need
+to be baked into the image, and any arguments after the flags are
+passed to it.
+
+Pods are selected in the same way as for exec: the user is prompted to
+select a pod unless --name, --selector or --all is provided, in which
+case the script runs in every matching pod concurrently and each line
+of output is prefixed by the pod it came from.
+
+To keep a script in the debug container so that it survives reconnects,
+mount it with "sonar create --script" instead.
+
+Global flags:
+
+Run "sonar help" in order to see flags which apply to all subcommands.
+
+Flags:
+
+--all (default: false)
+
+Runs the script in every matching pod without prompting for a
+selection.
+
+--script/-s
+
+Path to the script to run.
+
+--selector/-l (default: none)
+
+Only matches pods whose labels match the provided label selector (e.g.
+'team=network').
+
+--shell (default: '/bin/sh')
+
+Shell which reads the script from stdin.`,
+		Example: `
+"sonar run --script ./diag.sh" - prompts the user to select a Sonar pod
+and runs diag.sh in it.
+
+"sonar run --name netdebug --script ./check-dns.sh -- kubernetes.default"
+- runs check-dns.sh with the argument 'kubernetes.default' in every pod
+of the 'sonar-netdebug' session.`,
+		RunE: runRunCommand,
+	}
+
+	command.Flags().BoolVar(&all, "all", false, "run the script in all matching pods without prompting for a selection")
+	command.Flags().StringVarP(&script, "script", "s", "", "path to the script to run")
+	command.Flags().StringVarP(&selector, "selector", "l", "", "only match pods matching the label selector")
+	command.Flags().StringVar(&shell, "shell", "/bin/sh", "shell which reads the script from stdin")
+
+	return command
+}
+
+func runRunCommand(cmd *cobra.Command, args []string) error {
+	// Get the App instance from the command context
+	a, err := app.GetApp(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := a.Globals.RequireSingleContext("run"); err != nil {
+		return err
+	}
+
+	v, err := app.GetViper(cmd)
+	if err != nil {
+		return err
+	}
+
+	if script == "" {
+		return fmt.Errorf("--script must be provided")
+	}
+
+	data, err := os.ReadFile(script)
+	if err != nil {
+		return fmt.Errorf("could not read script: %w", err)
+	}
+
+	// Create a Kubernetes clientset.
+	k8sClientSet, err := k8sclient.New(a.Globals.KubeContext, a.Globals.KubeConfig)
+	if err != nil {
+		return err
+	}
+
+	// Labels used to match Sonar containers.
+	searchLabels := []string{"owner=sonar"}
+
+	// Only match the named session if a name was provided.
+	nameProvided := v.IsSet("name")
+	if nameProvided {
+		searchLabels = append(searchLabels, fmt.Sprintf("name=%s", a.Globals.Name))
+	}
+
+	// Add any user-provided selector.
+	if selector != "" {
+		searchLabels = append(searchLabels, selector)
+	}
+
+	// Get all pods matching the search labels.
+	ctx := a.Context
+	discoveredPods, err := utils.FindSonarPods(k8sClientSet, ctx, a.Globals.Name, a.Globals.Namespace, searchLabels)
+	if err != nil {
+		return err
+	}
+
+	// Scripts can only run in running pods.
+	var runningPods []types.DiscoveredPod
+	for _, pod := range discoveredPods {
+		if pod.Status == corev1.PodRunning {
+			runningPods = append(runningPods, pod)
+		}
+	}
+	if len(runningPods) == 0 {
+		return fmt.Errorf("no running pods found with labels %s: %w", strings.Join(searchLabels, ","), utils.ErrNoSessions)
+	}
+
+	// Run the script in every matching pod if the user asked for a
+	// specific set of pods, otherwise prompt them to select one.
+	selected := runningPods
+	if !all && !nameProvided && selector == "" && len(runningPods) > 1 {
+		selected, err = selectPod(runningPods)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Create a Kubernetes REST client for executing into the pods.
+	restClient, err := k8sclient.NewRestclient(a.Globals.KubeConfig, a.Globals.KubeContext)
+	if err != nil {
+		return err
+	}
+
+	// The shell reads the script from stdin and passes on the arguments.
+	podCommand := append([]string{shell, "-s", "--"}, args...)
+
+	log.Infof("running %s in %d pod(s)", script, len(selected))
+
+	if len(selected) == 1 {
+		return runScript(ctx, k8sClientSet, restClient, selected[0], podCommand, data, os.Stdout, os.Stderr)
+	}
+
+	return runScripts(ctx, k8sClientSet, restClient, selected, podCommand, data, term.IsTerminal(os.Stdout.Fd()))
+}
+
+// selectPod prompts the user to select one of the pods.
+func selectPod(pods []types.DiscoveredPod) ([]types.DiscoveredPod, error) {
+	var podList []string
+	for _, pod := range pods {
+		podList = append(podList, fmt.Sprintf("%s/%s", pod.Namespace, pod.Name))
+	}
+
+	// Prompt the user to select which pod to run the script in.
+	selectedPod, err := utils.DisplaySelectionPrompt("Select pod to run the script in", podList)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, name, _ := strings.Cut(selectedPod, "/")
+	for _, pod := range pods {
+		if pod.Namespace == namespace && pod.Name == name {
+			return []types.DiscoveredPod{pod}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("pod %s not found", selectedPod)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package run
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/glitchcrab/sonar/internal/k8sclient"
+	"github.com/glitchcrab/sonar/internal/types"
+	"github.com/glitchcrab/sonar/internal/utils"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// runScript streams the script to the command's stdin in the pod and
+// copies its output to stdout and stderr.
+func runScript(ctx context.Context, k8sClientSet *kubernetes.Clientset, restClient *restclient.Config, pod types.DiscoveredPod, podCommand []string, script []byte, stdout, stderr io.Writer) error {
+	options := &corev1.PodExecOptions{
+		Command: podCommand,
+		Stdin:   true,
+		Stdout:  true,
+		Stderr:  true,
+	}
+
+	executor, err := k8sclient.NewExecutor(k8sClientSet, restClient, pod.Namespace, pod.Name, options)
+	if err != nil {
+		return err
+	}
+
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  bytes.NewReader(script),
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+
+	var exitErr utilexec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Errorf("script failed in pod \"%s/%s\" with exit code %d", pod.Namespace, pod.Name, exitErr.ExitStatus())
+	} else if err != nil {
+		return fmt.Errorf("could not run script in pod \"%s/%s\": %w", pod.Namespace, pod.Name, err)
+	}
+
+	return nil
+}
+
+// runScripts runs the script in every pod concurrently, prefixing each
+// line of output with the pod it came from. Prefixes are coloured if
+// colour is set.
+func runScripts(ctx context.Context, k8sClientSet *kubernetes.Clientset, restClient *restclient.Config, pods []types.DiscoveredPod, podCommand []string, script []byte, colour bool) error {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := make([]error, len(pods))
+
+	for i, pod := range pods {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			name := fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)
+			stdout := utils.NewPrefixWriter(os.Stdout, &mu, name, i, colour)
+			stderr := utils.NewPrefixWriter(os.Stderr, &mu, name, i, colour)
+
+			errs[i] = runScript(ctx, k8sClientSet, restClient, pod, podCommand, script, stdout, stderr)
+			errs[i] = errors.Join(errs[i], stdout.Flush(), stderr.Flush())
+		}()
+	}
+
+	wg.Wait()
+
+	// Report the pods where the script succeeded; failures are returned.
+	for i, pod := range pods {
+		if errs[i] == nil {
+			log.WithContext(ctx).Infof("script succeeded in pod \"%s/%s\"", pod.Namespace, pod.Name)
+		}
+	}
+
+	return errors.Join(errs...)
+}
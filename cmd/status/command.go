@@ -0,0 +1,177 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/glitchcrab/sonar/internal/app"
+	"github.com/glitchcrab/sonar/internal/k8sclient"
+	"github.com/glitchcrab/sonar/internal/report"
+	"github.com/glitchcrab/sonar/internal/types"
+	"github.com/glitchcrab/sonar/internal/utils"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	output string
+)
+
+func NewCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:     "status",
+		Aliases: []string{"describe"},
+		Short:   "Shows the full status of a Sonar session",
+		Long: `Status gathers everything about a single Sonar session: the rollout
+state of its deployment or job, the conditions of its pods, the state of
+its containers along with why they last restarted, the node they run
+on, the effective security context, the attached NetworkPolicy, the
+ServiceAccount and the bindings which grant it permissions, recent
+events and the time left until the session's TTL expires.
+
+If --name is not provided, the user is prompted to select one of the
+sessions in the namespace (or in all namespaces, if no namespace was
+provided).
+
+Global flags:
+
+Run "sonar help" in order to see flags which apply to all subcommands.
+
+Flags:
+
+--output/-o (default: none)
+
+Output format. 'json' or 'yaml' prints the status as a document
+instead of text.`,
+		Example: `
+"sonar status" - prompts the user to select a session and shows its
+status.
+
+"sonar describe --name netdebug -n kube-system -o json" - prints the
+status of the 'sonar-netdebug' session as JSON.
+
+"sonar status -o yaml" - prints the status of the selected session as
+YAML.`,
+		RunE: runStatusCommand,
+	}
+
+	command.Flags().StringVarP(&output, "output", "o", "", "output format (json|yaml)")
+
+	return command
+}
+
+func runStatusCommand(cmd *cobra.Command, args []string) error {
+	// Get the App instance from the command context
+	a, err := app.GetApp(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := a.Globals.RequireSingleContext("status"); err != nil {
+		return err
+	}
+
+	if err := report.ValidateFormat(output); err != nil {
+		return err
+	}
+
+	v, err := app.GetViper(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Search all namespaces unless one was explicitly provided.
+	searchNamespace := a.Globals.Namespace
+	if a.Globals.NamespaceFromContext {
+		searchNamespace = ""
+	}
+
+	// Labels used to match Sonar sessions.
+	searchLabels := []string{"owner=sonar"}
+
+	// Only match the named session if a name was provided.
+	if v.IsSet("name") {
+		searchLabels = append(searchLabels, fmt.Sprintf("name=%s", a.Globals.Name))
+	}
+
+	// Create a Kubernetes clientset.
+	k8sClientSet, err := k8sclient.New(a.Globals.KubeContext, a.Globals.KubeConfig)
+	if err != nil {
+		return err
+	}
+
+	ctx := a.Context
+	sessions, err := utils.FindSonarSessions(k8sClientSet, ctx, a.Globals.Name, searchNamespace, searchLabels)
+	if err != nil {
+		return err
+	}
+
+	selected := sessions[0]
+	if len(sessions) > 1 {
+		selected, err = selectSession(sessions)
+		if err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	status, err := getStatus(k8sClientSet, ctx, selected, now)
+	if err != nil {
+		return err
+	}
+
+	switch output {
+	case report.FormatJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(status)
+	case report.FormatYAML:
+		data, err := yaml.Marshal(status)
+		if err != nil {
+			return fmt.Errorf("could not encode status: %w", err)
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	return printStatus(os.Stdout, status, now)
+}
+
+// selectSession prompts the user to select one of the sessions.
+func selectSession(sessions []types.DiscoveredSession) (types.DiscoveredSession, error) {
+	var sessionList []string
+	for _, s := range sessions {
+		sessionList = append(sessionList, fmt.Sprintf("%s/%s (%s)", s.Namespace, s.Name, s.Kind))
+	}
+
+	selected, err := utils.DisplaySelectionPrompt("Select session", sessionList)
+	if err != nil {
+		return types.DiscoveredSession{}, err
+	}
+
+	for i, item := range sessionList {
+		if item == selected {
+			return sessions[i], nil
+		}
+	}
+
+	return types.DiscoveredSession{}, fmt.Errorf("session %s not found", strings.TrimSpace(selected))
+}
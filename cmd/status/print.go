@@ -0,0 +1,176 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package status
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/glitchcrab/sonar/internal/describe"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// printStatus writes the status of the session to w in a readable form.
+func printStatus(w io.Writer, s *describe.Session, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Session:\t%s/%s\n", s.Namespace, s.Name)
+	fmt.Fprintf(tw, "Kind:\t%s\n", s.Kind)
+	fmt.Fprintf(tw, "Created:\t%s (%s ago)\n", s.Created.Format(time.RFC3339), duration.HumanDuration(now.Sub(s.Created)))
+	fmt.Fprintf(tw, "User:\t%s\n", valueOrNone(s.User))
+	fmt.Fprintf(tw, "Reason:\t%s\n", valueOrNone(s.Reason))
+	if s.ExpiresAt != nil && s.TTLRemaining == describe.Expired {
+		fmt.Fprintf(tw, "Expires:\t%s (expired)\n", s.ExpiresAt.Format(time.RFC3339))
+	} else if s.ExpiresAt != nil {
+		fmt.Fprintf(tw, "Expires:\t%s (in %s)\n", s.ExpiresAt.Format(time.RFC3339), s.TTLRemaining)
+	} else {
+		fmt.Fprintf(tw, "Expires:\t<never>\n")
+	}
+
+	if r := s.Rollout; r != nil {
+		if s.Kind == "job" {
+			fmt.Fprintf(tw, "Rollout:\t%s (%d active)\n", r.State, r.Ready)
+		} else {
+			fmt.Fprintf(tw, "Rollout:\t%s (%d desired, %d updated, %d ready, %d available)\n", r.State, r.Desired, r.Updated, r.Ready, r.Available)
+		}
+		if r.Message != "" {
+			fmt.Fprintf(tw, "\t%s\n", r.Message)
+		}
+	}
+
+	sa := s.ServiceAccount.Name
+	if s.ServiceAccount.Borrowed {
+		sa += " (borrowed)"
+	}
+	fmt.Fprintf(tw, "ServiceAccount:\t%s\n", sa)
+	if len(s.ServiceAccount.Bindings) == 0 {
+		fmt.Fprintf(tw, "Bindings:\t<none>\n")
+	}
+	for i, b := range s.ServiceAccount.Bindings {
+		label := ""
+		if i == 0 {
+			label = "Bindings:"
+		}
+		fmt.Fprintf(tw, "%s\t%s/%s -> %s/%s\n", label, b.Kind, b.Name, b.RoleKind, b.RoleName)
+	}
+
+	if np := s.NetworkPolicy; np != nil {
+		fmt.Fprintf(tw, "NetworkPolicy:\t%s (%s)\n", np.Name, strings.Join(np.PolicyTypes, ", "))
+	} else {
+		fmt.Fprintf(tw, "NetworkPolicy:\t<none>\n")
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, p := range s.Pods {
+		if err := printPod(w, p); err != nil {
+			return err
+		}
+	}
+	if len(s.Pods) == 0 {
+		fmt.Fprintf(w, "\nPods: <none>\n")
+	}
+
+	return printEvents(w, s.Events, now)
+}
+
+// printPod writes the status of a single pod to w.
+func printPod(w io.Writer, p describe.Pod) error {
+	fmt.Fprintf(w, "\nPod %s:\n", p.Name)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "  Node:\t%s\n", valueOrNone(p.Node))
+	fmt.Fprintf(tw, "  Phase:\t%s\n", p.Phase)
+	fmt.Fprintf(tw, "  IP:\t%s\n", valueOrNone(p.IP))
+
+	sc := p.SecurityContext
+	fmt.Fprintf(tw, "  Security:\tuser %s, group %s, non-root %t, privileged %t, privilege escalation %t\n",
+		int64OrNone(sc.RunAsUser), int64OrNone(sc.RunAsGroup), sc.RunAsNonRoot, sc.Privileged, sc.AllowPrivilegeEscalation)
+	fmt.Fprintf(tw, "  \tcapabilities added: %s, dropped: %s\n", listOrNone(sc.AddedCapabilities), listOrNone(sc.DroppedCapabilities))
+	fmt.Fprintf(tw, "  \thost network %t, host PID %t, host IPC %t\n", sc.HostNetwork, sc.HostPID, sc.HostIPC)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "  Conditions:\n")
+	tw = tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "    TYPE\tSTATUS\tREASON\tMESSAGE")
+	for _, c := range p.Conditions {
+		fmt.Fprintf(tw, "    %s\t%s\t%s\t%s\n", c.Type, c.Status, valueOrNone(c.Reason), valueOrNone(c.Message))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "  Containers:\n")
+	tw = tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "    NAME\tIMAGE\tSTATE\tREADY\tRESTARTS\tLAST TERMINATION")
+	for _, c := range p.Containers {
+		state := c.State
+		if c.Reason != "" {
+			state += " (" + c.Reason + ")"
+		}
+		last := "<none>"
+		if c.LastExitCode != nil {
+			last = fmt.Sprintf("%s (exit code %d)", c.LastReason, *c.LastExitCode)
+		}
+		fmt.Fprintf(tw, "    %s\t%s\t%s\t%t\t%d\t%s\n", c.Name, c.Image, state, c.Ready, c.Restarts, last)
+	}
+
+	return tw.Flush()
+}
+
+// printEvents writes the events to w.
+func printEvents(w io.Writer, events []describe.Event, now time.Time) error {
+	if len(events) == 0 {
+		fmt.Fprintf(w, "\nEvents: <none>\n")
+		return nil
+	}
+
+	fmt.Fprintf(w, "\nEvents:\n")
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "  LAST SEEN\tTYPE\tREASON\tOBJECT\tCOUNT\tMESSAGE")
+	for _, e := range events {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%d\t%s\n", duration.HumanDuration(now.Sub(e.LastSeen)), e.Type, e.Reason, e.Object, e.Count, e.Message)
+	}
+
+	return tw.Flush()
+}
+
+// valueOrNone returns a placeholder for empty values.
+func valueOrNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
+}
+
+// int64OrNone returns a placeholder for unset values.
+func int64OrNone(value *int64) string {
+	if value == nil {
+		return "<none>"
+	}
+	return fmt.Sprintf("%d", *value)
+}
+
+// listOrNone returns a placeholder for empty lists.
+func listOrNone(values []string) string {
+	return valueOrNone(strings.Join(values, ", "))
+}
@@ -0,0 +1,186 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package status
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/glitchcrab/sonar/internal/config"
+	"github.com/glitchcrab/sonar/internal/describe"
+	"github.com/glitchcrab/sonar/internal/types"
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// getStatus gathers the status of the session.
+func getStatus(k8sClientSet *kubernetes.Clientset, ctx context.Context, s types.DiscoveredSession, now time.Time) (*describe.Session, error) {
+	status := &describe.Session{
+		Name:      s.Name,
+		Namespace: s.Namespace,
+		Kind:      s.Kind,
+		Created:   s.Created,
+		User:      s.Annotations[config.AnnotationUser],
+		Reason:    s.Annotations[config.AnnotationReason],
+		Pods:      []describe.Pod{},
+		Events:    []describe.Event{},
+	}
+
+	if expiresAt, ok := s.Annotations[config.AnnotationExpiresAt]; ok {
+		expiry, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			log.Warnf("%s \"%s/%s\" has an invalid expiry time: %v", s.Kind, s.Namespace, s.Name, err)
+		} else {
+			status.ExpiresAt = &expiry
+			status.TTLRemaining = describe.Remaining(expiry, now)
+		}
+	}
+
+	rollout, err := getRollout(k8sClientSet, ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	status.Rollout = rollout
+
+	pods, err := k8sClientSet.CoreV1().Pods(s.Namespace).List(ctx, metav1.ListOptions{LabelSelector: sessionSelector(s)})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods: %w", err)
+	}
+
+	// Events are reported for the workload and everything it runs.
+	objects := map[string]bool{s.Name: true}
+	serviceAccount := s.Name
+	for _, pod := range pods.Items {
+		status.Pods = append(status.Pods, describe.NewPod(&pod))
+		objects[pod.Name] = true
+		if owner := metav1.GetControllerOf(&pod); owner != nil {
+			objects[owner.Name] = true
+		}
+		serviceAccount = pod.Spec.ServiceAccountName
+	}
+
+	status.ServiceAccount, err = getServiceAccount(k8sClientSet, ctx, s, serviceAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	np, err := k8sClientSet.NetworkingV1().NetworkPolicies(s.Namespace).Get(ctx, s.Name, metav1.GetOptions{})
+	if err == nil {
+		status.NetworkPolicy = &describe.NetworkPolicy{Name: np.Name}
+		for _, t := range np.Spec.PolicyTypes {
+			status.NetworkPolicy.PolicyTypes = append(status.NetworkPolicy.PolicyTypes, string(t))
+		}
+	} else if !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("error getting networkpolicy: %w", err)
+	}
+
+	status.Events, err = getEvents(k8sClientSet, ctx, s.Namespace, objects)
+	if err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// getRollout returns the rollout state of a Deployment or Job. Bare pods
+// have no rollout.
+func getRollout(k8sClientSet *kubernetes.Clientset, ctx context.Context, s types.DiscoveredSession) (*describe.Rollout, error) {
+	switch s.Kind {
+	case config.KindDeployment:
+		d, err := k8sClientSet.AppsV1().Deployments(s.Namespace).Get(ctx, s.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error getting deployment: %w", err)
+		}
+
+		return describe.DeploymentRollout(d), nil
+	case config.KindJob:
+		j, err := k8sClientSet.BatchV1().Jobs(s.Namespace).Get(ctx, s.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error getting job: %w", err)
+		}
+
+		return describe.JobRollout(j), nil
+	default:
+		return nil, nil
+	}
+}
+
+// getServiceAccount returns the ServiceAccount which the session runs as,
+// along with the RoleBindings and ClusterRoleBindings which grant it
+// permissions.
+func getServiceAccount(k8sClientSet *kubernetes.Clientset, ctx context.Context, s types.DiscoveredSession, name string) (describe.ServiceAccount, error) {
+	status := describe.ServiceAccount{
+		Name:     name,
+		Bindings: []describe.Binding{},
+	}
+	if borrowed, ok := s.Annotations[config.AnnotationBorrowedServiceAccount]; ok {
+		status.Name = borrowed
+		status.Borrowed = true
+	}
+
+	roleBindings, err := k8sClientSet.RbacV1().RoleBindings(s.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return status, fmt.Errorf("error listing rolebindings: %w", err)
+	}
+	for _, rb := range roleBindings.Items {
+		if describe.BindsServiceAccount(rb.Subjects, s.Namespace, status.Name) {
+			status.Bindings = append(status.Bindings, describe.Binding{Kind: "RoleBinding", Name: rb.Name, RoleKind: rb.RoleRef.Kind, RoleName: rb.RoleRef.Name})
+		}
+	}
+
+	// Listing ClusterRoleBindings needs cluster-wide permissions, which the
+	// user may not have.
+	clusterRoleBindings, err := k8sClientSet.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+	if apierrors.IsForbidden(err) {
+		log.Debugf("could not list clusterrolebindings: %v", err)
+		return status, nil
+	} else if err != nil {
+		return status, fmt.Errorf("error listing clusterrolebindings: %w", err)
+	}
+	for _, crb := range clusterRoleBindings.Items {
+		if describe.BindsServiceAccount(crb.Subjects, s.Namespace, status.Name) {
+			status.Bindings = append(status.Bindings, describe.Binding{Kind: "ClusterRoleBinding", Name: crb.Name, RoleKind: crb.RoleRef.Kind, RoleName: crb.RoleRef.Name})
+		}
+	}
+
+	return status, nil
+}
+
+// getEvents returns the most recent events about the objects, oldest
+// first.
+func getEvents(k8sClientSet *kubernetes.Clientset, ctx context.Context, namespace string, objects map[string]bool) ([]describe.Event, error) {
+	events, err := k8sClientSet.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing events: %w", err)
+	}
+
+	return describe.Events(events.Items, objects), nil
+}
+
+// sessionSelector returns the label selector which matches the session's
+// resources.
+func sessionSelector(s types.DiscoveredSession) string {
+	if id, ok := s.Labels[config.LabelSession]; ok {
+		return fmt.Sprintf("%s=%s", config.LabelSession, id)
+	}
+
+	// Sessions created by older versions of Sonar are not labelled with a
+	// session ID.
+	return fmt.Sprintf("owner=sonar,name=%s", s.Labels["name"])
+}
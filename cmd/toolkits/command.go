@@ -0,0 +1,118 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package toolkits
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/glitchcrab/sonar/internal/app"
+	"github.com/glitchcrab/sonar/internal/toolkit"
+	"github.com/spf13/cobra"
+)
+
+func NewCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:     "toolkits",
+		Aliases: []string{"toolkit"},
+		Short:   "Toolkits manages the catalog of debug toolkits",
+		Long: `Toolkits are curated debug images along with the command, the
+capabilities and the flags which they need. Use one with
+"sonar create --toolkit <name>".
+
+Sonar has a built-in catalog, which can be extended (or overridden)
+through the 'toolkits' key of the config file:
+
+  toolkits:
+    kafka:
+      description: Kafka client tools
+      image: registry.example.com/kafka-tools:v3
+      command: sleep
+      args: 24h
+      capabilities: []
+      root: false
+      flags: ["--networkpolicy"]
+
+Capabilities only take effect when the container runs as root, so
+toolkits which add any should also set 'root: true'. Their sessions
+then run as root unless --pod-userid or --non-root is provided.`,
+	}
+
+	command.AddCommand(newLsCommand())
+
+	return command
+}
+
+func newLsCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "Lists the available debug toolkits",
+		Example: `
+"sonar toolkits ls" - describes every toolkit in the catalog.`,
+		RunE: runLsCommand,
+	}
+
+	return command
+}
+
+func runLsCommand(cmd *cobra.Command, args []string) error {
+	v, err := app.GetViper(cmd)
+	if err != nil {
+		return err
+	}
+
+	catalog, err := toolkit.FromConfig(v)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSOURCE\tIMAGE\tCOMMAND\tCAPABILITIES\tUSER\tRECOMMENDED FLAGS\tDESCRIPTION")
+	for _, t := range catalog {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			t.Name,
+			t.Source,
+			t.Image,
+			valueOrNone(strings.TrimSpace(t.Command+" "+t.Args)),
+			valueOrNone(strings.Join(t.Capabilities, ",")),
+			user(t),
+			valueOrNone(strings.Join(t.Flags, " ")),
+			valueOrNone(t.Description),
+		)
+	}
+
+	return w.Flush()
+}
+
+// valueOrNone returns a placeholder for empty table values.
+func valueOrNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
+}
+
+// user describes who a toolkit's container runs as.
+func user(t toolkit.Toolkit) string {
+	if t.Root {
+		return "root"
+	}
+
+	return "non-root"
+}
@@ -16,8 +16,9 @@ limitations under the License.
 package version
 
 import (
+	"fmt"
+
 	"github.com/glitchcrab/sonar/pkg/project"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -30,7 +31,7 @@ func NewCommand() *cobra.Command {
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			version := project.Version()
-			log.Infof("version: %s\n", version)
+			fmt.Printf("version: %s\n", version)
 			return nil
 		},
 	}
@@ -1,6 +1,10 @@
 package app
 
-import "github.com/glitchcrab/sonar/internal/config"
+import (
+	"context"
+
+	"github.com/glitchcrab/sonar/internal/config"
+)
 
 const (
 	appKey   contextKey = "app"
@@ -11,6 +15,9 @@ type contextKey string
 
 // App is the initialised and validated runtime state.
 type App struct {
+	// Context is cancelled when the user interrupts Sonar or the global
+	// timeout expires. All API calls should use it.
+	Context context.Context
 	Globals config.Globals
 }
 
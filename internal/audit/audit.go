@@ -0,0 +1,63 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/glitchcrab/sonar/internal/config"
+	"github.com/glitchcrab/sonar/internal/k8sclient"
+	"github.com/glitchcrab/sonar/pkg/project"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	unknownUser = "unknown"
+)
+
+// Annotate adds the audit trail annotations (who, when, why and how) to a
+// CreateConfig so that they are set on every created resource. The
+// clientset may be nil (e.g. for a dry run), in which case the user is
+// taken from the kubeconfig only.
+func Annotate(k8sClientSet *kubernetes.Clientset, ctx context.Context, g config.Globals, o *config.CreateConfig) error {
+	if o.Annotations == nil {
+		o.Annotations = make(map[string]string)
+	}
+
+	options, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+
+	o.Annotations[config.AnnotationCreatedAt] = time.Now().UTC().Format(time.RFC3339)
+	o.Annotations[config.AnnotationOptions] = string(options)
+	o.Annotations[config.AnnotationUser] = user(k8sClientSet, ctx, g)
+	o.Annotations[config.AnnotationVersion] = project.Version()
+
+	if o.Reason != "" {
+		o.Annotations[config.AnnotationReason] = o.Reason
+	}
+
+	return nil
+}
+
+// user establishes who is creating the session. The API server's view
+// of the user is preferred, falling back to the kubeconfig user.
+func user(k8sClientSet *kubernetes.Clientset, ctx context.Context, g config.Globals) string {
+	if k8sClientSet != nil {
+		username, err := k8sclient.WhoAmI(k8sClientSet, ctx)
+		if err == nil {
+			return username
+		}
+		log.Debugf("could not look up user via selfsubjectreview: %v", err)
+	}
+
+	username, err := k8sclient.GetAuthInfo(g.KubeConfig, g.KubeContext)
+	if err != nil {
+		log.Warnf("could not establish user for audit annotations: %v", err)
+		return unknownUser
+	}
+
+	return username
+}
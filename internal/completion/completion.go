@@ -0,0 +1,208 @@
+package completion
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/glitchcrab/sonar/internal/config"
+	"github.com/glitchcrab/sonar/internal/k8sclient"
+	"github.com/glitchcrab/sonar/internal/toolkit"
+	"github.com/glitchcrab/sonar/internal/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// timeout bounds how long a completion may spend querying the cluster, so
+// that a slow or unreachable cluster doesn't hang the shell.
+const timeout = 2 * time.Second
+
+// Func completes the value of a flag or argument.
+type Func func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// Contexts completes the contexts in the kubeconfig.
+func Contexts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	kubeConfig, _ := cmd.Flags().GetString("kubeconfig")
+
+	contexts, err := k8sclient.ResolveContexts(kubeConfig, nil, true)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	return matching(contexts, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// Namespaces completes the namespaces in the cluster.
+func Namespaces(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return query(cmd, func(ctx context.Context, k8sClientSet *kubernetes.Clientset, namespace string) ([]string, error) {
+		namespaces, err := k8sClientSet.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		var names []string
+		for _, ns := range namespaces.Items {
+			names = append(names, ns.Name)
+		}
+
+		return names, nil
+	}, toComplete)
+}
+
+// Nodes completes the nodes in the cluster.
+func Nodes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return query(cmd, func(ctx context.Context, k8sClientSet *kubernetes.Clientset, namespace string) ([]string, error) {
+		nodes, err := k8sClientSet.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		var names []string
+		for _, node := range nodes.Items {
+			names = append(names, node.Name)
+		}
+
+		return names, nil
+	}, toComplete)
+}
+
+// Pods completes the pods in the selected namespace. Only a single pod
+// may be provided.
+func Pods(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return query(cmd, func(ctx context.Context, k8sClientSet *kubernetes.Clientset, namespace string) ([]string, error) {
+		pods, err := k8sClientSet.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		var names []string
+		for _, pod := range pods.Items {
+			names = append(names, pod.Name)
+		}
+
+		return names, nil
+	}, toComplete)
+}
+
+// Names completes the names of existing Sonar sessions, as provided to
+// --name. Sessions in every namespace are offered unless a namespace was
+// provided.
+func Names(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return query(cmd, func(ctx context.Context, k8sClientSet *kubernetes.Clientset, namespace string) ([]string, error) {
+		if !cmd.Flags().Changed("namespace") {
+			namespace = ""
+		}
+
+		sessions, err := utils.ListSonarSessions(k8sClientSet, ctx, namespace, metav1.ListOptions{LabelSelector: "owner=sonar"})
+		if err != nil {
+			return nil, err
+		}
+
+		var names []string
+		for _, s := range sessions {
+			if name := s.Labels["name"]; name != "" {
+				names = append(names, fmt.Sprintf("%s\t%s/%s (%s)", name, s.Namespace, s.Name, s.Kind))
+			}
+		}
+
+		return names, nil
+	}, toComplete)
+}
+
+// Toolkits completes the toolkits in the catalog, including any custom
+// toolkits from the config file.
+func Toolkits(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	v := viper.New()
+	configFile, _ := cmd.Flags().GetString("config")
+	if configFile == "" {
+		configFile, _ = config.FindConfigFile()
+	}
+	if configFile != "" {
+		v.SetConfigFile(configFile)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+	}
+
+	catalog, err := toolkit.FromConfig(v)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var names []string
+	for _, t := range catalog {
+		names = append(names, fmt.Sprintf("%s\t%s", t.Name, t.Description))
+	}
+
+	return matching(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// Values completes a fixed set of values.
+func Values(values ...string) Func {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return matching(values, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// query runs a lookup against the cluster selected by the command's
+// flags, bounded by the completion timeout. Nothing is completed if the
+// cluster can't be reached in time.
+func query(cmd *cobra.Command, lookup func(ctx context.Context, k8sClientSet *kubernetes.Clientset, namespace string) ([]string, error), toComplete string) ([]string, cobra.ShellCompDirective) {
+	kubeConfig, _ := cmd.Flags().GetString("kubeconfig")
+
+	// Use the first selected context, or the current context.
+	kubeContext := ""
+	if patterns, _ := cmd.Flags().GetStringSlice("context"); len(patterns) > 0 {
+		contexts, err := k8sclient.ResolveContexts(kubeConfig, patterns, false)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		kubeContext = contexts[0]
+	}
+
+	namespace, _ := cmd.Flags().GetString("namespace")
+	if namespace == "" {
+		namespace, _ = k8sclient.GetNamespace(kubeConfig, kubeContext)
+	}
+
+	k8sClientSet, err := k8sclient.New(kubeContext, kubeConfig)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+	defer cancel()
+
+	values, err := lookup(ctx, k8sClientSet, namespace)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	return matching(values, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// matching returns the sorted, unique values which start with prefix.
+// Values may carry a tab-separated description.
+func matching(values []string, prefix string) []string {
+	seen := make(map[string]bool)
+	var matched []string
+	for _, value := range values {
+		name, _, _ := strings.Cut(value, "\t")
+		if !strings.HasPrefix(name, prefix) || seen[name] {
+			continue
+		}
+		seen[name] = true
+		matched = append(matched, value)
+	}
+	sort.Strings(matched)
+
+	return matched
+}
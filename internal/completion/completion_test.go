@@ -0,0 +1,57 @@
+package completion
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestMatching(t *testing.T) {
+	testCases := []struct {
+		name    string
+		values  []string
+		prefix  string
+		matched []string
+	}{
+		{
+			name:    "test no prefix",
+			values:  []string{"kube-system", "default"},
+			matched: []string{"default", "kube-system"},
+		},
+		{
+			name:    "test prefix",
+			values:  []string{"kube-system", "default", "kube-public"},
+			prefix:  "kube-",
+			matched: []string{"kube-public", "kube-system"},
+		},
+		{
+			name:   "test no matches",
+			values: []string{"kube-system", "default"},
+			prefix: "monitoring",
+		},
+		{
+			name:    "test descriptions",
+			values:  []string{"debug\tdefault/sonar-debug (deployment)", "net\tteam-a/sonar-net (pod)"},
+			prefix:  "d",
+			matched: []string{"debug\tdefault/sonar-debug (deployment)"},
+		},
+		{
+			name: "test duplicate names",
+			values: []string{
+				"debug\tdefault/sonar-debug (deployment)",
+				"debug\tteam-a/sonar-debug (job)",
+			},
+			matched: []string{"debug\tdefault/sonar-debug (deployment)"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			matched := matching(testCase.values, testCase.prefix)
+
+			if diff := deep.Equal(matched, testCase.matched); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
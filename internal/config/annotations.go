@@ -0,0 +1,31 @@
+package config
+
+const (
+	// annotationPrefix is the prefix used for all annotations set by Sonar.
+	annotationPrefix = "sonar.a7d.io/"
+
+	// AnnotationBorrowedServiceAccount records the name of an existing
+	// ServiceAccount which a session runs as instead of its own.
+	AnnotationBorrowedServiceAccount = annotationPrefix + "borrowed-service-account"
+
+	// AnnotationCreatedAt records the time (RFC3339) at which a session
+	// was created.
+	AnnotationCreatedAt = annotationPrefix + "created-at"
+
+	// AnnotationExpiresAt records the time (RFC3339) at which a session's
+	// TTL expires.
+	AnnotationExpiresAt = annotationPrefix + "expires-at"
+
+	// AnnotationOptions records the effective create options as JSON.
+	AnnotationOptions = annotationPrefix + "options"
+
+	// AnnotationReason records the user-provided reason for a session.
+	AnnotationReason = annotationPrefix + "reason"
+
+	// AnnotationUser records the user who created a session.
+	AnnotationUser = annotationPrefix + "user"
+
+	// AnnotationVersion records the version of Sonar which created a
+	// session.
+	AnnotationVersion = annotationPrefix + "version"
+)
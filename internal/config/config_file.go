@@ -22,7 +22,7 @@ func FindConfigFile() (string, error) {
 	// Find the user's home dir
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		log.Info("could not establish user's home dir")
+		log.Debug("could not establish user's home dir")
 		return "", err
 	}
 
@@ -1,22 +1,91 @@
 package config
 
-// CreateConfig contains the create-specific user-provided configuration
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CreateConfig contains the create-specific user-provided configuration,
+// the fields of which are recorded on the created resources using their
+// JSON names (see AnnotationOptions).
 type CreateConfig struct {
-	DryRun              bool
-	FullName            string
-	Image               string
-	Labels              map[string]string
-	Name                string
-	Namespace           string
-	NetworkPolicy       bool
-	NodeExec            bool
-	NodeName            string
-	NonRoot             bool
-	PodArgs             string
-	PodCommand          string
-	PodGroup            int64
-	PodUser             int64
-	Privileged          bool
-	PrivilegeEscalation bool
-	UnprivilegedPing    bool
+	AllowedRegistries []string          `json:"-"`
+	Annotations       map[string]string `json:"-"`
+	Args              []string          `json:"args,omitempty"`
+	Capabilities      []string          `json:"capabilities,omitempty"`
+	// At most one of ClusterRole, Role and ReadOnlyResources may be set;
+	// the session's ServiceAccount is bound to it within the session's
+	// namespace.
+	ClusterRole    string   `json:"clusterRole,omitempty"`
+	Command        []string `json:"command,omitempty"`
+	CopyPullSecret string   `json:"copyPullSecret,omitempty"`
+	DryRun         bool     `json:"-"`
+	// Env is not recorded as its values may be sensitive.
+	Env              []string `json:"-"`
+	EnvFrom          []string `json:"envFrom,omitempty"`
+	FullName         string   `json:"-"`
+	Image            string   `json:"image"`
+	ImagePullPolicy  string   `json:"imagePullPolicy,omitempty"`
+	ImagePullSecrets []string `json:"imagePullSecrets,omitempty"`
+	KeepOnFailure    bool     `json:"-"`
+	// Kind selects the workload which runs the debug container (see Kinds).
+	Kind                string                  `json:"kind,omitempty"`
+	Labels              map[string]string       `json:"-"`
+	Like                string                  `json:"like,omitempty"`
+	LikeSpec            *corev1.PodSpec         `json:"-"`
+	Mounts              []Mount                 `json:"mounts,omitempty"`
+	Name                string                  `json:"-"`
+	Namespace           string                  `json:"-"`
+	NetworkPolicy       bool                    `json:"networkPolicy"`
+	NodeExec            bool                    `json:"nodeExec"`
+	NodeName            string                  `json:"nodeName,omitempty"`
+	NoToken             bool                    `json:"noToken"`
+	NonRoot             bool                    `json:"nonRoot"`
+	Output              string                  `json:"-"`
+	OwnerReferences     []metav1.OwnerReference `json:"-"`
+	PodArgs             string                  `json:"podArgs,omitempty"`
+	PodCommand          string                  `json:"podCommand,omitempty"`
+	PodGroup            int64                   `json:"podGroup"`
+	PodUser             int64                   `json:"podUser"`
+	Privileged          bool                    `json:"privileged"`
+	PrivilegeEscalation bool                    `json:"privilegeEscalation"`
+	ReadOnlyResources   []string                `json:"readOnlyResources,omitempty"`
+	Reason              string                  `json:"-"`
+	Role                string                  `json:"role,omitempty"`
+	// Scripts maps file names to the contents of local scripts which are
+	// mounted into the container at ScriptsPath.
+	Scripts          map[string]string `json:"-"`
+	ServiceAccount   string            `json:"serviceAccount,omitempty"`
+	Toolkit          string            `json:"toolkit,omitempty"`
+	TTL              time.Duration     `json:"-"`
+	UnprivilegedPing bool              `json:"unprivilegedPing"`
+}
+
+// CopiedPullSecretName returns the name of the session's copy of
+// CopyPullSecret.
+func (c CreateConfig) CopiedPullSecretName() string {
+	return c.FullName + "-pull-secret"
+}
+
+// ScriptsConfigMapName returns the name of the ConfigMap which holds the
+// session's Scripts.
+func (c CreateConfig) ScriptsConfigMapName() string {
+	return c.FullName + "-scripts"
+}
+
+// ServiceAccountName returns the name of the ServiceAccount which the
+// debug pod runs as: either a borrowed one, or the session's own.
+func (c CreateConfig) ServiceAccountName() string {
+	if c.ServiceAccount != "" {
+		return c.ServiceAccount
+	}
+
+	return c.FullName
+}
+
+// BindsRole returns true if the session's ServiceAccount is bound to a role.
+func (c CreateConfig) BindsRole() bool {
+	return c.ClusterRole != "" || c.Role != "" || len(c.ReadOnlyResources) > 0
 }
@@ -1,8 +1,31 @@
 package config
 
+import "fmt"
+
 // DeleteConfig contains the delete-specific user-provided configuration
 type DeleteConfig struct {
 	SearchLabels []string
 	Name         string
 	Namespace    string
 }
+
+// NewDeleteConfig returns a DeleteConfig which matches every resource
+// belonging to the session with the provided deployment namespace, name
+// and labels.
+func NewDeleteConfig(namespace, name string, labels map[string]string) DeleteConfig {
+	// Match on the session ID across all namespaces.
+	if id, ok := labels[LabelSession]; ok {
+		return DeleteConfig{
+			SearchLabels: []string{fmt.Sprintf("%s=%s", LabelSession, id)},
+			Name:         name,
+		}
+	}
+
+	// Sessions created by older versions of Sonar are not labelled with a
+	// session ID, so fall back to the name label within the namespace.
+	return DeleteConfig{
+		SearchLabels: []string{"owner=sonar", fmt.Sprintf("name=%s", labels["name"])},
+		Name:         name,
+		Namespace:    namespace,
+	}
+}
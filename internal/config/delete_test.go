@@ -0,0 +1,64 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestNewDeleteConfig(t *testing.T) {
+	id := SessionID("default", "sonar-test")
+
+	testCases := []struct {
+		name   string
+		labels map[string]string
+		output DeleteConfig
+	}{
+		{
+			name: "test session labelled with an ID",
+			labels: map[string]string{
+				"name":       "test",
+				"owner":      "sonar",
+				LabelSession: id,
+			},
+			output: DeleteConfig{
+				SearchLabels: []string{LabelSession + "=" + id},
+				Name:         "sonar-test",
+			},
+		},
+		{
+			name: "test legacy session without an ID",
+			labels: map[string]string{
+				"name":  "test",
+				"owner": "sonar",
+			},
+			output: DeleteConfig{
+				SearchLabels: []string{"owner=sonar", "name=test"},
+				Name:         "sonar-test",
+				Namespace:    "default",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got := NewDeleteConfig("default", "sonar-test", testCase.labels)
+			if diff := deep.Equal(got, testCase.output); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestSessionID(t *testing.T) {
+	// Session IDs must be stable, unique per namespace and valid label values.
+	if SessionID("default", "sonar-test") != SessionID("default", "sonar-test") {
+		t.Error("session ID is not stable")
+	}
+	if SessionID("default", "sonar-test") == SessionID("other", "sonar-test") {
+		t.Error("session ID does not depend on the namespace")
+	}
+	if len(SessionID("default", "sonar-test")) > 63 {
+		t.Error("session ID is too long to be a label value")
+	}
+}
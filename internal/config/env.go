@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ImagePullPolicies lists the supported image pull policies.
+var ImagePullPolicies = []string{"Always", "IfNotPresent", "Never"}
+
+// ParseEnv parses an environment variable in KEY=VALUE form. The value may
+// be empty.
+func ParseEnv(s string) (string, string, error) {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || key == "" || strings.ContainsAny(key, " \t") {
+		return "", "", fmt.Errorf("invalid env %q: expected KEY=VALUE", s)
+	}
+
+	return key, value, nil
+}
+
+// ParseEnvFrom parses a source of environment variables in
+// configmap/<name> or secret/<name> form.
+func ParseEnvFrom(s string) (string, string, error) {
+	kind, name, ok := strings.Cut(s, "/")
+	kind = strings.ToLower(kind)
+	if !ok || name == "" || (kind != "configmap" && kind != "secret") {
+		return "", "", fmt.Errorf("invalid env-from %q: expected configmap/<name> or secret/<name>", s)
+	}
+
+	return kind, name, nil
+}
+
+// ParseSecretRef parses a reference to a Secret in <namespace>/<name> form.
+func ParseSecretRef(s string) (string, string, error) {
+	namespace, name, ok := strings.Cut(s, "/")
+	if !ok || namespace == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("invalid secret %q: expected <namespace>/<name>", s)
+	}
+
+	return namespace, name, nil
+}
+
+// ValidateImagePullPolicy returns an error if the policy is not supported.
+// An empty policy leaves the choice to Kubernetes.
+func ValidateImagePullPolicy(policy string) error {
+	if policy == "" {
+		return nil
+	}
+
+	for _, p := range ImagePullPolicies {
+		if policy == p {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid image pull policy %q: expected one of %s", policy, strings.Join(ImagePullPolicies, ", "))
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestParseEnv(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		output  []string
+		wantErr bool
+	}{
+		{
+			name:   "test key and value",
+			input:  "HTTP_PROXY=http://proxy:3128",
+			output: []string{"HTTP_PROXY", "http://proxy:3128"},
+		},
+		{
+			name:   "test value containing equals",
+			input:  "OPTS=-Dfoo=bar",
+			output: []string{"OPTS", "-Dfoo=bar"},
+		},
+		{
+			name:   "test empty value",
+			input:  "NO_PROXY=",
+			output: []string{"NO_PROXY", ""},
+		},
+		{
+			name:    "test missing value",
+			input:   "NO_PROXY",
+			wantErr: true,
+		},
+		{
+			name:    "test missing key",
+			input:   "=value",
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			key, value, err := ParseEnv(testCase.input)
+			if testCase.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := deep.Equal([]string{key, value}, testCase.output); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestParseEnvFrom(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		output  []string
+		wantErr bool
+	}{
+		{
+			name:   "test configmap",
+			input:  "configmap/proxy",
+			output: []string{"configmap", "proxy"},
+		},
+		{
+			name:   "test secret",
+			input:  "Secret/credentials",
+			output: []string{"secret", "credentials"},
+		},
+		{
+			name:    "test unsupported kind",
+			input:   "pod/app",
+			wantErr: true,
+		},
+		{
+			name:    "test missing name",
+			input:   "configmap/",
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			kind, name, err := ParseEnvFrom(testCase.input)
+			if testCase.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := deep.Equal([]string{kind, name}, testCase.output); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
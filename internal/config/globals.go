@@ -1,11 +1,29 @@
 package config
 
+import (
+	"fmt"
+	"time"
+)
+
 // Globals contains the global user-provided configuration
 type Globals struct {
-	KubeConfig  string
-	KubeContext string
-	FullName    string
-	Name        string
-	Namespace   string
-	Labels      map[string]string
+	ClusterTimeout       time.Duration
+	KubeConfig           string
+	KubeContext          string
+	KubeContexts         []string
+	FullName             string
+	Name                 string
+	Namespace            string
+	NamespaceFromContext bool
+	Labels               map[string]string
+}
+
+// RequireSingleContext returns an error if more than one kubeconfig context
+// was selected, for commands which can only operate on a single cluster.
+func (g Globals) RequireSingleContext(command string) error {
+	if len(g.KubeContexts) > 1 {
+		return fmt.Errorf("%s only supports a single context (%d selected)", command, len(g.KubeContexts))
+	}
+
+	return nil
 }
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/distribution/reference"
+)
+
+// NormaliseImage parses an image reference using the distribution/reference
+// grammar and adds the 'latest' tag if neither a tag nor a digest was
+// provided. The familiar form (e.g. 'busybox:latest' rather than
+// 'docker.io/library/busybox:latest') is returned.
+func NormaliseImage(image string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", fmt.Errorf("invalid image %q: %w", image, err)
+	}
+
+	return reference.FamiliarString(reference.TagNameOnly(named)), nil
+}
+
+// ImageAllowed reports whether an image may be pulled from one of the
+// allowed registries. Each entry is either a registry host (with an
+// optional port), such as 'registry.example.com:5000' or 'docker.io', or a
+// repository prefix, such as 'ghcr.io/example'. If no registries are
+// provided then every image is allowed.
+func ImageAllowed(image string, allowed []string) (bool, error) {
+	if len(allowed) == 0 {
+		return true, nil
+	}
+
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return false, fmt.Errorf("invalid image %q: %w", image, err)
+	}
+
+	domain := reference.Domain(named)
+	name := named.Name()
+	for _, a := range allowed {
+		a = strings.TrimSuffix(a, "/")
+		if a == domain || strings.HasPrefix(name, a+"/") {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
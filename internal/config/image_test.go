@@ -0,0 +1,123 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestNormaliseImage(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		output  string
+		wantErr bool
+	}{
+		{
+			name:   "test image without a tag",
+			input:  "busybox",
+			output: "busybox:latest",
+		},
+		{
+			name:   "test image with a tag",
+			input:  "glitchcrab/ubuntu-debug:v1.0",
+			output: "glitchcrab/ubuntu-debug:v1.0",
+		},
+		{
+			name:   "test registry with a port and no tag",
+			input:  "registry.example.com:5000/debug",
+			output: "registry.example.com:5000/debug:latest",
+		},
+		{
+			name:   "test registry with a port and a tag",
+			input:  "registry.example.com:5000/debug:v2",
+			output: "registry.example.com:5000/debug:v2",
+		},
+		{
+			name:   "test digest",
+			input:  "busybox@sha256:7b3ccabffc97de872a30dfd234fd972a66d247c8cfc69b0550f276481852627c",
+			output: "busybox@sha256:7b3ccabffc97de872a30dfd234fd972a66d247c8cfc69b0550f276481852627c",
+		},
+		{
+			name:   "test underscores",
+			input:  "example/debug_tools:v1_2",
+			output: "example/debug_tools:v1_2",
+		},
+		{
+			name:    "test uppercase repository",
+			input:   "Example/Debug",
+			wantErr: true,
+		},
+		{
+			name:    "test whitespace",
+			input:   "busybox latest",
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			image, err := NormaliseImage(testCase.input)
+			if testCase.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if image != testCase.output {
+				t.Errorf("expected %q, got %q", testCase.output, image)
+			}
+		})
+	}
+}
+
+func TestImageAllowed(t *testing.T) {
+	allowed := []string{"registry.example.com:5000", "ghcr.io/example/"}
+
+	testCases := []struct {
+		name   string
+		image  string
+		output bool
+	}{
+		{
+			name:   "test allowed registry",
+			image:  "registry.example.com:5000/debug:v1",
+			output: true,
+		},
+		{
+			name:   "test allowed repository prefix",
+			image:  "ghcr.io/example/debug:v1",
+			output: true,
+		},
+		{
+			name:   "test other repository in the same registry",
+			image:  "ghcr.io/other/debug:v1",
+			output: false,
+		},
+		{
+			name:   "test docker hub",
+			image:  "busybox:latest",
+			output: false,
+		},
+		{
+			name:   "test registry without the port",
+			image:  "registry.example.com/debug:v1",
+			output: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ok, err := ImageAllowed(testCase.image, allowed)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if ok != testCase.output {
+				t.Errorf("expected %t, got %t", testCase.output, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kinds of workload which run a session's debug container.
+const (
+	// KindDeployment is a long-lived session which is rescheduled if its
+	// pod is lost.
+	KindDeployment = "deployment"
+	// KindJob runs the debug container to completion.
+	KindJob = "job"
+	// KindPod is a bare pod which is never rescheduled or restarted.
+	KindPod = "pod"
+)
+
+// Kinds lists the supported workload kinds.
+var Kinds = []string{KindDeployment, KindJob, KindPod}
+
+// ParseKind returns the workload kind in canonical form. An empty kind
+// defaults to KindDeployment.
+func ParseKind(kind string) (string, error) {
+	if kind == "" {
+		return KindDeployment, nil
+	}
+
+	kind = strings.ToLower(kind)
+	for _, k := range Kinds {
+		if kind == k {
+			return k, nil
+		}
+	}
+
+	return "", fmt.Errorf("invalid kind %q: expected one of %s", kind, strings.Join(Kinds, ", "))
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestParseKind(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		output  string
+		wantErr bool
+	}{
+		{
+			name:   "test default",
+			input:  "",
+			output: KindDeployment,
+		},
+		{
+			name:   "test job",
+			input:  "job",
+			output: KindJob,
+		},
+		{
+			name:   "test mixed case",
+			input:  "Pod",
+			output: KindPod,
+		},
+		{
+			name:    "test unsupported kind",
+			input:   "statefulset",
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			kind, err := ParseKind(testCase.input)
+			if testCase.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if kind != testCase.output {
+				t.Errorf("expected %q, got %q", testCase.output, kind)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+const (
+	// LabelSession uniquely identifies the session which a resource
+	// belongs to, across all namespaces.
+	LabelSession = annotationPrefix + "session"
+
+	// sessionIDLength is the number of hex characters used for session
+	// IDs. Label values are limited to 63 characters.
+	sessionIDLength = 20
+)
+
+// SessionID returns a stable identifier for the session with the provided
+// namespace and (full) name. Running create again for the same session
+// always produces the same ID.
+func SessionID(namespace, fullName string) string {
+	sum := sha256.Sum256([]byte(namespace + "/" + fullName))
+
+	return hex.EncodeToString(sum[:])[:sessionIDLength]
+}
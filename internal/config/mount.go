@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Kinds of volume which can be mounted into the debug container.
+const (
+	MountConfigMap = "configmap"
+	MountEmptyDir  = "emptydir"
+	MountPVC       = "pvc"
+	MountSecret    = "secret"
+)
+
+// Mount describes a volume which is mounted into the debug container.
+type Mount struct {
+	Kind     string `json:"kind"`
+	Source   string `json:"source,omitempty"`
+	Path     string `json:"path"`
+	ReadOnly bool   `json:"readOnly,omitempty"`
+}
+
+// ParseMount parses a mount in one of the following forms:
+//
+//	configmap:<name>:<path>
+//	secret:<name>:<path>
+//	pvc:<name>:<path>[:ro]
+//	emptydir:<path>
+//
+// ConfigMaps and Secrets are always mounted read-only.
+func ParseMount(s string) (Mount, error) {
+	fields := strings.Split(s, ":")
+
+	var m Mount
+	switch kind := strings.ToLower(fields[0]); kind {
+	case MountConfigMap, MountSecret:
+		if len(fields) != 3 {
+			return m, fmt.Errorf("invalid mount %q: expected %s:<name>:<path>", s, kind)
+		}
+		m = Mount{Kind: kind, Source: fields[1], Path: fields[2], ReadOnly: true}
+	case MountPVC:
+		if len(fields) < 3 || len(fields) > 4 || (len(fields) == 4 && fields[3] != "ro") {
+			return m, fmt.Errorf("invalid mount %q: expected pvc:<name>:<path>[:ro]", s)
+		}
+		m = Mount{Kind: kind, Source: fields[1], Path: fields[2], ReadOnly: len(fields) == 4}
+	case MountEmptyDir:
+		if len(fields) != 2 {
+			return m, fmt.Errorf("invalid mount %q: expected emptydir:<path>", s)
+		}
+		m = Mount{Kind: kind, Path: fields[1]}
+	default:
+		return m, fmt.Errorf("invalid mount %q: unsupported kind %q (expected configmap, secret, pvc or emptydir)", s, fields[0])
+	}
+
+	if m.Kind != MountEmptyDir && m.Source == "" {
+		return m, fmt.Errorf("invalid mount %q: name must not be empty", s)
+	}
+
+	if !path.IsAbs(m.Path) {
+		return m, fmt.Errorf("invalid mount %q: path must be absolute", s)
+	}
+	m.Path = path.Clean(m.Path)
+
+	return m, nil
+}
+
+// ParseMounts parses each mount, rejecting any which share a path.
+func ParseMounts(mounts []string) ([]Mount, error) {
+	var parsed []Mount
+	paths := make(map[string]bool)
+	for _, s := range mounts {
+		m, err := ParseMount(s)
+		if err != nil {
+			return nil, err
+		}
+
+		if paths[m.Path] {
+			return nil, fmt.Errorf("invalid mount %q: path %s is already mounted", s, m.Path)
+		}
+		paths[m.Path] = true
+
+		parsed = append(parsed, m)
+	}
+
+	return parsed, nil
+}
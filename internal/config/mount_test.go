@@ -0,0 +1,88 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestParseMount(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		output  Mount
+		wantErr bool
+	}{
+		{
+			name:   "test configmap",
+			input:  "configmap:app-config:/config",
+			output: Mount{Kind: MountConfigMap, Source: "app-config", Path: "/config", ReadOnly: true},
+		},
+		{
+			name:   "test secret",
+			input:  "secret:app-tls:/tls/",
+			output: Mount{Kind: MountSecret, Source: "app-tls", Path: "/tls", ReadOnly: true},
+		},
+		{
+			name:   "test read-write pvc",
+			input:  "pvc:data:/data",
+			output: Mount{Kind: MountPVC, Source: "data", Path: "/data"},
+		},
+		{
+			name:   "test read-only pvc",
+			input:  "pvc:data:/data:ro",
+			output: Mount{Kind: MountPVC, Source: "data", Path: "/data", ReadOnly: true},
+		},
+		{
+			name:   "test emptydir",
+			input:  "emptydir:/scratch",
+			output: Mount{Kind: MountEmptyDir, Path: "/scratch"},
+		},
+		{
+			name:    "test unsupported kind",
+			input:   "hostpath:/:/host",
+			wantErr: true,
+		},
+		{
+			name:    "test relative path",
+			input:   "configmap:app-config:config",
+			wantErr: true,
+		},
+		{
+			name:    "test missing name",
+			input:   "secret::/tls",
+			wantErr: true,
+		},
+		{
+			name:    "test invalid pvc mode",
+			input:   "pvc:data:/data:rw",
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			m, err := ParseMount(testCase.input)
+			if testCase.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := deep.Equal(m, testCase.output); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestParseMountsDuplicatePath(t *testing.T) {
+	_, err := ParseMounts([]string{"emptydir:/data", "pvc:data:/data/"})
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
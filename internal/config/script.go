@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ScriptsPath is where a session's scripts are mounted in the debug
+// container.
+const ScriptsPath = "/sonar/scripts"
+
+// ReadScripts reads local scripts, keyed by their file names, so that they
+// can be shipped to the debug container in a ConfigMap.
+func ReadScripts(paths []string) (map[string]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	scripts := make(map[string]string, len(paths))
+	for _, p := range paths {
+		name := filepath.Base(p)
+		if errs := validation.IsConfigMapKey(name); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid script name %q: %s", name, errs[0])
+		}
+		if _, ok := scripts[name]; ok {
+			return nil, fmt.Errorf("script %s is provided more than once", name)
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("could not read script: %w", err)
+		}
+		scripts[name] = string(data)
+	}
+
+	return scripts, nil
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestReadScripts(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"diag.sh":       "#!/bin/sh\necho diag\n",
+		"other/diag.sh": "#!/bin/sh\necho other\n",
+		"dns.sh":        "#!/bin/sh\ndig kubernetes\n",
+	} {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		name    string
+		input   []string
+		output  map[string]string
+		wantErr bool
+	}{
+		{
+			name:  "test no scripts",
+			input: nil,
+		},
+		{
+			name:  "test scripts",
+			input: []string{filepath.Join(dir, "diag.sh"), filepath.Join(dir, "dns.sh")},
+			output: map[string]string{
+				"diag.sh": "#!/bin/sh\necho diag\n",
+				"dns.sh":  "#!/bin/sh\ndig kubernetes\n",
+			},
+		},
+		{
+			name:    "test duplicate file names",
+			input:   []string{filepath.Join(dir, "diag.sh"), filepath.Join(dir, "other", "diag.sh")},
+			wantErr: true,
+		},
+		{
+			name:    "test missing script",
+			input:   []string{filepath.Join(dir, "missing.sh")},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			scripts, err := ReadScripts(testCase.input)
+			if testCase.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := deep.Equal(scripts, testCase.output); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -72,6 +72,7 @@ func ValidateGlobalConfig(g *Globals) error {
 		if err != nil {
 			errs = append(errs, err)
 		}
+		g.NamespaceFromContext = true
 	}
 
 	// If there were any validation errors, return them as a single error.
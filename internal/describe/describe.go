@@ -0,0 +1,344 @@
+package describe
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+const (
+	// Expired is reported as the time remaining once a session's TTL has
+	// expired.
+	Expired = "expired"
+
+	// MaxEvents is the number of most recent events which are reported.
+	MaxEvents = 20
+)
+
+// Session is the full picture of a single session.
+type Session struct {
+	Name           string         `json:"name"`
+	Namespace      string         `json:"namespace"`
+	Kind           string         `json:"kind"`
+	Created        time.Time      `json:"created"`
+	User           string         `json:"user,omitempty"`
+	Reason         string         `json:"reason,omitempty"`
+	ExpiresAt      *time.Time     `json:"expiresAt,omitempty"`
+	TTLRemaining   string         `json:"ttlRemaining,omitempty"`
+	Rollout        *Rollout       `json:"rollout,omitempty"`
+	Pods           []Pod          `json:"pods"`
+	ServiceAccount ServiceAccount `json:"serviceAccount"`
+	NetworkPolicy  *NetworkPolicy `json:"networkPolicy,omitempty"`
+	Events         []Event        `json:"events"`
+}
+
+// Rollout is the state of the session's Deployment or Job.
+type Rollout struct {
+	State     string `json:"state"`
+	Desired   int32  `json:"desired"`
+	Updated   int32  `json:"updated,omitempty"`
+	Ready     int32  `json:"ready"`
+	Available int32  `json:"available,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+// Pod is the state of one of the session's pods.
+type Pod struct {
+	Name            string          `json:"name"`
+	Node            string          `json:"node,omitempty"`
+	Phase           string          `json:"phase"`
+	IP              string          `json:"ip,omitempty"`
+	Conditions      []Condition     `json:"conditions"`
+	Containers      []Container     `json:"containers"`
+	SecurityContext SecurityContext `json:"securityContext"`
+}
+
+// Condition is a pod condition.
+type Condition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Container is the state of a container, along with why it last
+// terminated.
+type Container struct {
+	Name         string `json:"name"`
+	Image        string `json:"image"`
+	State        string `json:"state"`
+	Reason       string `json:"reason,omitempty"`
+	Ready        bool   `json:"ready"`
+	Restarts     int32  `json:"restarts"`
+	LastReason   string `json:"lastReason,omitempty"`
+	LastExitCode *int32 `json:"lastExitCode,omitempty"`
+}
+
+// SecurityContext is the effective security context of the debug
+// container, taking the pod's security context into account.
+type SecurityContext struct {
+	RunAsUser                *int64   `json:"runAsUser,omitempty"`
+	RunAsGroup               *int64   `json:"runAsGroup,omitempty"`
+	RunAsNonRoot             bool     `json:"runAsNonRoot"`
+	Privileged               bool     `json:"privileged"`
+	AllowPrivilegeEscalation bool     `json:"allowPrivilegeEscalation"`
+	AddedCapabilities        []string `json:"addedCapabilities,omitempty"`
+	DroppedCapabilities      []string `json:"droppedCapabilities,omitempty"`
+	HostNetwork              bool     `json:"hostNetwork"`
+	HostPID                  bool     `json:"hostPID"`
+	HostIPC                  bool     `json:"hostIPC"`
+}
+
+// ServiceAccount is the ServiceAccount which the session runs as, along
+// with the bindings which grant it permissions.
+type ServiceAccount struct {
+	Name     string    `json:"name"`
+	Borrowed bool      `json:"borrowed"`
+	Bindings []Binding `json:"bindings"`
+}
+
+// Binding is a RoleBinding or ClusterRoleBinding.
+type Binding struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	RoleKind string `json:"roleKind"`
+	RoleName string `json:"roleName"`
+}
+
+// NetworkPolicy is the session's NetworkPolicy.
+type NetworkPolicy struct {
+	Name        string   `json:"name"`
+	PolicyTypes []string `json:"policyTypes"`
+}
+
+// Event is an event about one of the session's objects.
+type Event struct {
+	LastSeen time.Time `json:"lastSeen"`
+	Type     string    `json:"type"`
+	Reason   string    `json:"reason"`
+	Object   string    `json:"object"`
+	Count    int32     `json:"count"`
+	Message  string    `json:"message"`
+}
+
+// DeploymentRollout returns the rollout state of a Deployment, in the
+// same terms as "kubectl rollout status".
+func DeploymentRollout(d *appsv1.Deployment) *Rollout {
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+
+	rollout := &Rollout{
+		State:     "progressing",
+		Desired:   desired,
+		Updated:   d.Status.UpdatedReplicas,
+		Ready:     d.Status.ReadyReplicas,
+		Available: d.Status.AvailableReplicas,
+	}
+
+	for _, c := range d.Status.Conditions {
+		if c.Type == appsv1.DeploymentProgressing && c.Reason == "ProgressDeadlineExceeded" {
+			rollout.State = "failed"
+			rollout.Message = c.Message
+			return rollout
+		}
+	}
+
+	if d.Status.ObservedGeneration >= d.Generation && rollout.Updated == desired && rollout.Available == desired && d.Status.Replicas == desired {
+		rollout.State = "complete"
+	}
+
+	return rollout
+}
+
+// JobRollout returns the state of a Job.
+func JobRollout(j *batchv1.Job) *Rollout {
+	rollout := &Rollout{State: "running", Desired: 1, Ready: j.Status.Active}
+	switch {
+	case j.Status.Succeeded > 0:
+		rollout.State = "succeeded"
+	case j.Status.Failed > 0:
+		rollout.State = "failed"
+	}
+	for _, c := range j.Status.Conditions {
+		if c.Status == corev1.ConditionTrue && c.Message != "" {
+			rollout.Message = c.Message
+		}
+	}
+
+	return rollout
+}
+
+// NewPod returns the status of a pod and its debug container.
+func NewPod(pod *corev1.Pod) Pod {
+	status := Pod{
+		Name:       pod.Name,
+		Node:       pod.Spec.NodeName,
+		Phase:      string(pod.Status.Phase),
+		IP:         pod.Status.PodIP,
+		Conditions: []Condition{},
+		Containers: []Container{},
+	}
+
+	for _, c := range pod.Status.Conditions {
+		status.Conditions = append(status.Conditions, Condition{
+			Type:    string(c.Type),
+			Status:  string(c.Status),
+			Reason:  c.Reason,
+			Message: c.Message,
+		})
+	}
+
+	for _, c := range pod.Status.ContainerStatuses {
+		status.Containers = append(status.Containers, NewContainer(c))
+	}
+
+	status.SecurityContext = EffectiveSecurityContext(pod)
+
+	return status
+}
+
+// NewContainer returns the state of a container, along with the reason it
+// last terminated if it was restarted.
+func NewContainer(c corev1.ContainerStatus) Container {
+	status := Container{
+		Name:     c.Name,
+		Image:    c.Image,
+		State:    "unknown",
+		Ready:    c.Ready,
+		Restarts: c.RestartCount,
+	}
+
+	switch {
+	case c.State.Running != nil:
+		status.State = "running"
+	case c.State.Waiting != nil:
+		status.State = "waiting"
+		status.Reason = c.State.Waiting.Reason
+	case c.State.Terminated != nil:
+		status.State = "terminated"
+		status.Reason = c.State.Terminated.Reason
+	}
+
+	if t := c.LastTerminationState.Terminated; t != nil {
+		status.LastReason = t.Reason
+		status.LastExitCode = &t.ExitCode
+	}
+
+	return status
+}
+
+// EffectiveSecurityContext returns the security context of the debug
+// container, falling back to the pod's security context.
+func EffectiveSecurityContext(pod *corev1.Pod) SecurityContext {
+	status := SecurityContext{
+		HostIPC:     pod.Spec.HostIPC,
+		HostNetwork: pod.Spec.HostNetwork,
+		HostPID:     pod.Spec.HostPID,
+	}
+
+	if psc := pod.Spec.SecurityContext; psc != nil {
+		status.RunAsUser = psc.RunAsUser
+		status.RunAsGroup = psc.RunAsGroup
+		status.RunAsNonRoot = psc.RunAsNonRoot != nil && *psc.RunAsNonRoot
+	}
+
+	var sc *corev1.SecurityContext
+	for _, c := range pod.Spec.Containers {
+		if c.Name == "sonar" {
+			sc = c.SecurityContext
+		}
+	}
+	if sc == nil && len(pod.Spec.Containers) > 0 {
+		sc = pod.Spec.Containers[0].SecurityContext
+	}
+	if sc == nil {
+		return status
+	}
+
+	if sc.RunAsUser != nil {
+		status.RunAsUser = sc.RunAsUser
+	}
+	if sc.RunAsGroup != nil {
+		status.RunAsGroup = sc.RunAsGroup
+	}
+	if sc.RunAsNonRoot != nil {
+		status.RunAsNonRoot = *sc.RunAsNonRoot
+	}
+	status.Privileged = sc.Privileged != nil && *sc.Privileged
+	status.AllowPrivilegeEscalation = sc.AllowPrivilegeEscalation != nil && *sc.AllowPrivilegeEscalation
+	if sc.Capabilities != nil {
+		for _, c := range sc.Capabilities.Add {
+			status.AddedCapabilities = append(status.AddedCapabilities, string(c))
+		}
+		for _, c := range sc.Capabilities.Drop {
+			status.DroppedCapabilities = append(status.DroppedCapabilities, string(c))
+		}
+	}
+
+	return status
+}
+
+// BindsServiceAccount returns true if the subjects include the
+// ServiceAccount.
+func BindsServiceAccount(subjects []rbacv1.Subject, namespace, name string) bool {
+	for _, subject := range subjects {
+		if subject.Kind == rbacv1.ServiceAccountKind && subject.Namespace == namespace && subject.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Events returns the MaxEvents most recent events about the objects,
+// oldest first.
+func Events(events []corev1.Event, objects map[string]bool) []Event {
+	statuses := []Event{}
+	for _, e := range events {
+		if !objects[e.InvolvedObject.Name] {
+			continue
+		}
+
+		lastSeen := e.LastTimestamp.Time
+		if lastSeen.IsZero() {
+			lastSeen = e.EventTime.Time
+		}
+		if lastSeen.IsZero() {
+			lastSeen = e.CreationTimestamp.Time
+		}
+
+		statuses = append(statuses, Event{
+			LastSeen: lastSeen,
+			Type:     e.Type,
+			Reason:   e.Reason,
+			Object:   strings.ToLower(e.InvolvedObject.Kind) + "/" + e.InvolvedObject.Name,
+			Count:    max(e.Count, 1),
+			Message:  strings.TrimSpace(e.Message),
+		})
+	}
+
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return statuses[i].LastSeen.Before(statuses[j].LastSeen)
+	})
+	if len(statuses) > MaxEvents {
+		statuses = statuses[len(statuses)-MaxEvents:]
+	}
+
+	return statuses
+}
+
+// Remaining returns the time left until expiry.
+func Remaining(expiry, now time.Time) string {
+	if !expiry.After(now) {
+		return Expired
+	}
+
+	return expiry.Sub(now).Round(time.Second).String()
+}
@@ -0,0 +1,451 @@
+package describe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDeploymentRollout(t *testing.T) {
+	replicas := int32(2)
+
+	testCases := []struct {
+		name       string
+		deployment *appsv1.Deployment
+		expected   *Rollout
+	}{
+		{
+			name: "test complete",
+			deployment: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+				Status: appsv1.DeploymentStatus{
+					ObservedGeneration: 2,
+					Replicas:           2,
+					UpdatedReplicas:    2,
+					ReadyReplicas:      2,
+					AvailableReplicas:  2,
+				},
+			},
+			expected: &Rollout{State: "complete", Desired: 2, Updated: 2, Ready: 2, Available: 2},
+		},
+		{
+			name: "test default replicas",
+			deployment: &appsv1.Deployment{
+				Status: appsv1.DeploymentStatus{
+					Replicas:          1,
+					UpdatedReplicas:   1,
+					ReadyReplicas:     1,
+					AvailableReplicas: 1,
+				},
+			},
+			expected: &Rollout{State: "complete", Desired: 1, Updated: 1, Ready: 1, Available: 1},
+		},
+		{
+			name: "test progressing",
+			deployment: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{Replicas: &replicas},
+				Status: appsv1.DeploymentStatus{
+					Replicas:          2,
+					UpdatedReplicas:   2,
+					ReadyReplicas:     1,
+					AvailableReplicas: 1,
+				},
+			},
+			expected: &Rollout{State: "progressing", Desired: 2, Updated: 2, Ready: 1, Available: 1},
+		},
+		{
+			name: "test old generation",
+			deployment: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Generation: 3},
+				Status: appsv1.DeploymentStatus{
+					ObservedGeneration: 2,
+					Replicas:           1,
+					UpdatedReplicas:    1,
+					ReadyReplicas:      1,
+					AvailableReplicas:  1,
+				},
+			},
+			expected: &Rollout{State: "progressing", Desired: 1, Updated: 1, Ready: 1, Available: 1},
+		},
+		{
+			name: "test progress deadline exceeded",
+			deployment: &appsv1.Deployment{
+				Status: appsv1.DeploymentStatus{
+					Replicas: 1,
+					Conditions: []appsv1.DeploymentCondition{
+						{
+							Type:    appsv1.DeploymentProgressing,
+							Status:  corev1.ConditionFalse,
+							Reason:  "ProgressDeadlineExceeded",
+							Message: `ReplicaSet "sonar-debug-5d9c" has timed out progressing.`,
+						},
+					},
+				},
+			},
+			expected: &Rollout{State: "failed", Desired: 1, Message: `ReplicaSet "sonar-debug-5d9c" has timed out progressing.`},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if diff := deep.Equal(DeploymentRollout(testCase.deployment), testCase.expected); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestJobRollout(t *testing.T) {
+	testCases := []struct {
+		name     string
+		status   batchv1.JobStatus
+		expected *Rollout
+	}{
+		{
+			name:     "test running",
+			status:   batchv1.JobStatus{Active: 1},
+			expected: &Rollout{State: "running", Desired: 1, Ready: 1},
+		},
+		{
+			name: "test succeeded",
+			status: batchv1.JobStatus{
+				Succeeded: 1,
+				Conditions: []batchv1.JobCondition{
+					{Type: batchv1.JobComplete, Status: corev1.ConditionTrue},
+				},
+			},
+			expected: &Rollout{State: "succeeded", Desired: 1},
+		},
+		{
+			name: "test failed",
+			status: batchv1.JobStatus{
+				Failed: 1,
+				Conditions: []batchv1.JobCondition{
+					{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "Job has reached the specified backoff limit"},
+				},
+			},
+			expected: &Rollout{State: "failed", Desired: 1, Message: "Job has reached the specified backoff limit"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if diff := deep.Equal(JobRollout(&batchv1.Job{Status: testCase.status}), testCase.expected); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestNewContainer(t *testing.T) {
+	exitCode := int32(137)
+
+	testCases := []struct {
+		name     string
+		status   corev1.ContainerStatus
+		expected Container
+	}{
+		{
+			name: "test running",
+			status: corev1.ContainerStatus{
+				Name:  "sonar",
+				Image: "busybox:latest",
+				Ready: true,
+				State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			},
+			expected: Container{Name: "sonar", Image: "busybox:latest", State: "running", Ready: true},
+		},
+		{
+			name: "test waiting",
+			status: corev1.ContainerStatus{
+				Name:  "sonar",
+				Image: "busybox:latest",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+			},
+			expected: Container{Name: "sonar", Image: "busybox:latest", State: "waiting", Reason: "ImagePullBackOff"},
+		},
+		{
+			name: "test restarted",
+			status: corev1.ContainerStatus{
+				Name:                 "sonar",
+				Image:                "busybox:latest",
+				RestartCount:         3,
+				State:                corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}},
+			},
+			expected: Container{Name: "sonar", Image: "busybox:latest", State: "running", Restarts: 3, LastReason: "OOMKilled", LastExitCode: &exitCode},
+		},
+		{
+			name: "test terminated",
+			status: corev1.ContainerStatus{
+				Name:  "sonar",
+				Image: "busybox:latest",
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Completed"}},
+			},
+			expected: Container{Name: "sonar", Image: "busybox:latest", State: "terminated", Reason: "Completed"},
+		},
+		{
+			name:     "test unknown",
+			status:   corev1.ContainerStatus{Name: "sonar", Image: "busybox:latest"},
+			expected: Container{Name: "sonar", Image: "busybox:latest", State: "unknown"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if diff := deep.Equal(NewContainer(testCase.status), testCase.expected); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestEffectiveSecurityContext(t *testing.T) {
+	podUser, containerUser, group := int64(1000), int64(0), int64(2000)
+	yes, no := true, false
+
+	testCases := []struct {
+		name     string
+		spec     corev1.PodSpec
+		expected SecurityContext
+	}{
+		{
+			name: "test no security context",
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "sonar"}},
+			},
+		},
+		{
+			name: "test pod security context",
+			spec: corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{RunAsUser: &podUser, RunAsGroup: &group, RunAsNonRoot: &yes},
+				Containers:      []corev1.Container{{Name: "sonar"}},
+			},
+			expected: SecurityContext{RunAsUser: &podUser, RunAsGroup: &group, RunAsNonRoot: true},
+		},
+		{
+			name: "test container overrides pod",
+			spec: corev1.PodSpec{
+				HostNetwork:     true,
+				HostPID:         true,
+				HostIPC:         true,
+				SecurityContext: &corev1.PodSecurityContext{RunAsUser: &podUser, RunAsGroup: &group, RunAsNonRoot: &yes},
+				Containers: []corev1.Container{
+					{
+						Name: "sonar",
+						SecurityContext: &corev1.SecurityContext{
+							RunAsUser:                &containerUser,
+							RunAsNonRoot:             &no,
+							Privileged:               &yes,
+							AllowPrivilegeEscalation: &yes,
+							Capabilities: &corev1.Capabilities{
+								Add:  []corev1.Capability{"NET_ADMIN", "NET_RAW"},
+								Drop: []corev1.Capability{"ALL"},
+							},
+						},
+					},
+				},
+			},
+			expected: SecurityContext{
+				RunAsUser:                &containerUser,
+				RunAsGroup:               &group,
+				Privileged:               true,
+				AllowPrivilegeEscalation: true,
+				AddedCapabilities:        []string{"NET_ADMIN", "NET_RAW"},
+				DroppedCapabilities:      []string{"ALL"},
+				HostNetwork:              true,
+				HostPID:                  true,
+				HostIPC:                  true,
+			},
+		},
+		{
+			name: "test sonar container",
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "istio-proxy", SecurityContext: &corev1.SecurityContext{RunAsUser: &podUser}},
+					{Name: "sonar", SecurityContext: &corev1.SecurityContext{RunAsUser: &containerUser}},
+				},
+			},
+			expected: SecurityContext{RunAsUser: &containerUser},
+		},
+		{
+			name: "test first container",
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "debug", SecurityContext: &corev1.SecurityContext{Privileged: &yes}},
+				},
+			},
+			expected: SecurityContext{Privileged: true},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if diff := deep.Equal(EffectiveSecurityContext(&corev1.Pod{Spec: testCase.spec}), testCase.expected); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestBindsServiceAccount(t *testing.T) {
+	testCases := []struct {
+		name     string
+		subjects []rbacv1.Subject
+		expected bool
+	}{
+		{
+			name: "test bound",
+			subjects: []rbacv1.Subject{
+				{Kind: rbacv1.UserKind, Name: "jane"},
+				{Kind: rbacv1.ServiceAccountKind, Namespace: "default", Name: "sonar-debug"},
+			},
+			expected: true,
+		},
+		{
+			name: "test other namespace",
+			subjects: []rbacv1.Subject{
+				{Kind: rbacv1.ServiceAccountKind, Namespace: "kube-system", Name: "sonar-debug"},
+			},
+		},
+		{
+			name: "test user of the same name",
+			subjects: []rbacv1.Subject{
+				{Kind: rbacv1.UserKind, Namespace: "default", Name: "sonar-debug"},
+			},
+		},
+		{
+			name: "test no subjects",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if bound := BindsServiceAccount(testCase.subjects, "default", "sonar-debug"); bound != testCase.expected {
+				t.Errorf("expected %t, got %t", testCase.expected, bound)
+			}
+		})
+	}
+}
+
+func TestEvents(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	event := func(object string, age time.Duration, count int32) corev1.Event {
+		return corev1.Event{
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: object},
+			LastTimestamp:  metav1.NewTime(now.Add(-age)),
+			Type:           corev1.EventTypeNormal,
+			Reason:         "Pulled",
+			Count:          count,
+			Message:        "pulled image \n",
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		events   []corev1.Event
+		expected []Event
+	}{
+		{
+			name:     "test no events",
+			expected: []Event{},
+		},
+		{
+			name: "test filtered and sorted",
+			events: []corev1.Event{
+				event("sonar-debug-abc", time.Minute, 2),
+				event("other-pod", 2*time.Minute, 1),
+				event("sonar-debug-abc", 5*time.Minute, 0),
+			},
+			expected: []Event{
+				{LastSeen: now.Add(-5 * time.Minute), Type: "Normal", Reason: "Pulled", Object: "pod/sonar-debug-abc", Count: 1, Message: "pulled image"},
+				{LastSeen: now.Add(-time.Minute), Type: "Normal", Reason: "Pulled", Object: "pod/sonar-debug-abc", Count: 2, Message: "pulled image"},
+			},
+		},
+		{
+			name: "test event time",
+			events: []corev1.Event{
+				{
+					InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "sonar-debug-abc"},
+					EventTime:      metav1.NewMicroTime(now),
+					Type:           corev1.EventTypeWarning,
+					Reason:         "BackOff",
+				},
+			},
+			expected: []Event{
+				{LastSeen: now, Type: "Warning", Reason: "BackOff", Object: "pod/sonar-debug-abc", Count: 1},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			events := Events(testCase.events, map[string]bool{"sonar-debug-abc": true})
+
+			if diff := deep.Equal(events, testCase.expected); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestEventsLimit(t *testing.T) {
+	now := time.Now()
+
+	var events []corev1.Event
+	for i := 0; i < MaxEvents+5; i++ {
+		events = append(events, corev1.Event{
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "sonar-debug-abc"},
+			LastTimestamp:  metav1.NewTime(now.Add(time.Duration(i) * time.Second)),
+		})
+	}
+
+	limited := Events(events, map[string]bool{"sonar-debug-abc": true})
+	if len(limited) != MaxEvents {
+		t.Fatalf("expected %d events, got %d", MaxEvents, len(limited))
+	}
+	if !limited[MaxEvents-1].LastSeen.Equal(events[len(events)-1].LastTimestamp.Time) {
+		t.Errorf("expected the most recent event last, got %v", limited[MaxEvents-1].LastSeen)
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		expiry   time.Time
+		expected string
+	}{
+		{
+			name:     "test remaining",
+			expiry:   now.Add(90*time.Minute + 400*time.Millisecond),
+			expected: "1h30m0s",
+		},
+		{
+			name:     "test expiring now",
+			expiry:   now,
+			expected: Expired,
+		},
+		{
+			name:     "test expired",
+			expiry:   now.Add(-time.Hour),
+			expected: Expired,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if diff := deep.Equal(Remaining(testCase.expiry, now), testCase.expected); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
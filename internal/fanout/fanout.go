@@ -0,0 +1,77 @@
+package fanout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/glitchcrab/sonar/internal/logging"
+)
+
+// Result is the outcome of running a function against a single context.
+type Result[T any] struct {
+	Context string
+	Value   T
+	Err     error
+}
+
+// Run calls fn concurrently for each of the provided kubeconfig contexts,
+// giving each call its own timeout. Results are returned in the same order
+// as the contexts. Logs written with the context passed to fn are
+// attributed to its cluster.
+func Run[T any](ctx context.Context, contexts []string, timeout time.Duration, fn func(ctx context.Context, kubeContext string) (T, error)) []Result[T] {
+	results := make([]Result[T], len(contexts))
+
+	var wg sync.WaitGroup
+	for i, kubeContext := range contexts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Give each cluster its own deadline so that one slow cluster
+			// doesn't hold up the others.
+			clusterCtx := logging.WithCluster(ctx, kubeContext)
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				clusterCtx, cancel = context.WithTimeout(clusterCtx, timeout)
+				defer cancel()
+			}
+
+			value, err := fn(clusterCtx, kubeContext)
+			results[i] = Result[T]{
+				Context: kubeContext,
+				Value:   value,
+				Err:     err,
+			}
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// Summarise writes a per-context summary of the results to w and returns
+// the joined errors of any contexts which failed.
+func Summarise[T any](w io.Writer, results []Result[T], describe func(T) string) error {
+	var errs []error
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "CONTEXT\tRESULT\tDETAILS")
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Fprintf(tw, "%s\tfailed\t%v\n", result.Context, result.Err)
+			errs = append(errs, fmt.Errorf("context %q: %w", result.Context, result.Err))
+			continue
+		}
+		fmt.Fprintf(tw, "%s\tok\t%s\n", result.Context, describe(result.Value))
+	}
+	if err := tw.Flush(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
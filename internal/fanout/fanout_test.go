@@ -0,0 +1,79 @@
+package fanout
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glitchcrab/sonar/internal/logging"
+	"github.com/go-test/deep"
+)
+
+func TestRun(t *testing.T) {
+	contexts := []string{"slow", "fast", "broken"}
+
+	results := Run(context.Background(), contexts, 50*time.Millisecond, func(ctx context.Context, kubeContext string) (string, error) {
+		switch kubeContext {
+		case "slow":
+			<-ctx.Done()
+			return "", ctx.Err()
+		case "broken":
+			return "", errors.New("broken")
+		default:
+			return kubeContext, nil
+		}
+	})
+
+	var gotContexts []string
+	for _, r := range results {
+		gotContexts = append(gotContexts, r.Context)
+	}
+	if diff := deep.Equal(gotContexts, contexts); diff != nil {
+		t.Errorf("results out of order: %v", diff)
+	}
+
+	if !errors.Is(results[0].Err, context.DeadlineExceeded) {
+		t.Errorf("expected slow context to time out, got %v", results[0].Err)
+	}
+	if results[1].Err != nil || results[1].Value != "fast" {
+		t.Errorf("unexpected result for fast context: %+v", results[1])
+	}
+	if results[2].Err == nil {
+		t.Errorf("expected error for broken context")
+	}
+}
+
+func TestRunCluster(t *testing.T) {
+	testCases := []struct {
+		name    string
+		timeout time.Duration
+	}{
+		{
+			name: "test no timeout",
+		},
+		{
+			name:    "test timeout",
+			timeout: time.Minute,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			contexts := []string{"prod-a", "prod-b"}
+
+			results := Run(context.Background(), contexts, testCase.timeout, func(ctx context.Context, kubeContext string) (string, error) {
+				cluster, _ := logging.Cluster(ctx)
+				return cluster, nil
+			})
+
+			var clusters []string
+			for _, r := range results {
+				clusters = append(clusters, r.Value)
+			}
+			if diff := deep.Equal(clusters, contexts); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
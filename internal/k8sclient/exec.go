@@ -0,0 +1,50 @@
+/*
+Copyright © 2021 Simon Weald
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package k8sclient
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// NewExecutor returns an Executor which runs a command in a pod. Websocket
+// is preferred, with SPDY as a fallback.
+func NewExecutor(k8sClientSet *kubernetes.Clientset, restClient *restclient.Config, namespace, pod string, options *corev1.PodExecOptions) (remotecommand.Executor, error) {
+	request := k8sClientSet.CoreV1().
+		RESTClient().
+		Post().
+		Resource("pods").
+		Name(pod).
+		Namespace(namespace).
+		SubResource("exec")
+
+	request.VersionedParams(
+		options,
+		scheme.ParameterCodec,
+	)
+
+	// Try WebSocket first
+	exec, err := remotecommand.NewWebSocketExecutor(restClient, "POST", request.URL().String())
+	if err == nil {
+		return exec, nil
+	}
+
+	// Fallback to SPDY
+	return remotecommand.NewSPDYExecutor(restClient, "POST", request.URL())
+}
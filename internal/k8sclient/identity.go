@@ -0,0 +1,60 @@
+package k8sclient
+
+import (
+	"context"
+	"fmt"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// WhoAmI returns the username which the API server has authenticated the
+// client as, using a SelfSubjectReview.
+func WhoAmI(k8sClientSet *kubernetes.Clientset, ctx context.Context) (string, error) {
+	review, err := k8sClientSet.AuthenticationV1().SelfSubjectReviews().Create(ctx, &authenticationv1.SelfSubjectReview{}, metav1.CreateOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	if review.Status.UserInfo.Username == "" {
+		return "", fmt.Errorf("selfsubjectreview returned no username")
+	}
+
+	return review.Status.UserInfo.Username, nil
+}
+
+// GetAuthInfo returns the name of the user (AuthInfo) referenced by a
+// context in a Kubeconfig.
+func GetAuthInfo(kubeConfigPath, kubeContext string) (string, error) {
+	var err error
+	var context string
+
+	// Discover the kubeconfig if an explicit path wasn't provided
+	if kubeConfigPath == "" {
+		kubeConfigPath, err = findKubeConfig()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	kubeConfig, err := clientcmd.LoadFromFile(kubeConfigPath)
+	if err != nil {
+		return "", err
+	}
+
+	// Use the context if it was provided, otherwise use the current context
+	if kubeContext != "" {
+		context = kubeContext
+	} else {
+		context = kubeConfig.CurrentContext
+	}
+
+	c, ok := kubeConfig.Contexts[context]
+	if !ok || c.AuthInfo == "" {
+		return "", fmt.Errorf("no user set (context: %s, kubeconfig: %s)", context, kubeConfigPath)
+	}
+
+	return c.AuthInfo, nil
+}
@@ -16,8 +16,12 @@ limitations under the License.
 package k8sclient
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path"
+	"sort"
+	"strings"
 
 	"github.com/mitchellh/go-homedir"
 	"k8s.io/client-go/kubernetes"
@@ -41,7 +45,7 @@ func findKubeConfig() (string, error) {
 	}
 
 	// Inform the user which kubeconfig file is being used
-	log.Infof("using kubeconfig: %s", path)
+	log.Debugf("using kubeconfig: %s", path)
 
 	return path, nil
 }
@@ -89,18 +93,118 @@ func New(kubeContext, kubeConfigPath string) (*kubernetes.Clientset, error) {
 
 	config, err := NewRestclient(kubeConfigPath, kubeContext)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	// Create a Clientset with the provided values.
 	k8sClientSet, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	return k8sClientSet, err
 }
 
+// ResolveContexts expands the provided context names and glob patterns into
+// a sorted list of contexts from the kubeconfig. If all is true then every
+// context in the kubeconfig is returned.
+func ResolveContexts(kubeConfigPath string, patterns []string, all bool) ([]string, error) {
+	var err error
+
+	// Discover the kubeconfig if an explicit path wasn't provided
+	if kubeConfigPath == "" {
+		kubeConfigPath, err = findKubeConfig()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	kubeConfig, err := clientcmd.LoadFromFile(kubeConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var contexts []string
+	for name := range kubeConfig.Contexts {
+		contexts = append(contexts, name)
+	}
+	sort.Strings(contexts)
+
+	if all {
+		return contexts, nil
+	}
+
+	// Match each pattern against the available contexts, preserving the
+	// sorted order and dropping duplicates.
+	var errs []error
+	matched := make(map[string]bool)
+	for _, pattern := range patterns {
+		if _, err := matchContext(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("invalid context pattern %q: %w", pattern, err))
+			continue
+		}
+
+		found := false
+		for _, name := range contexts {
+			if ok, _ := matchContext(pattern, name); ok {
+				matched[name] = true
+				found = true
+			}
+		}
+
+		if !found {
+			errs = append(errs, fmt.Errorf("no contexts matching %q found in kubeconfig %s", pattern, kubeConfigPath))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	var resolved []string
+	for _, name := range contexts {
+		if matched[name] {
+			resolved = append(resolved, name)
+		}
+	}
+
+	return resolved, nil
+}
+
+// matchContext reports whether the context name matches the glob pattern.
+// Unlike path.Match, '*' and '?' also match '/', as context names such as
+// EKS's "arn:aws:eks:<region>:<account>:cluster/<name>" aren't paths.
+func matchContext(pattern, name string) (bool, error) {
+	const separator = "\x00"
+
+	return path.Match(strings.ReplaceAll(pattern, "/", separator), strings.ReplaceAll(name, "/", separator))
+}
+
+// ContextName returns the name of the provided context, or of the current
+// context if none was provided.
+func ContextName(kubeConfigPath, kubeContext string) (string, error) {
+	if kubeContext != "" {
+		return kubeContext, nil
+	}
+
+	var err error
+
+	// Discover the kubeconfig if an explicit path wasn't provided
+	if kubeConfigPath == "" {
+		kubeConfigPath, err = findKubeConfig()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	kubeConfig, err := clientcmd.LoadFromFile(kubeConfigPath)
+	if err != nil {
+		return "", err
+	}
+
+	return kubeConfig.CurrentContext, nil
+}
+
 // GetNamespace returns the current namespace from a Kubeconfig
 func GetNamespace(kubeConfigPath, kubeContext string) (string, error) {
 	var err error
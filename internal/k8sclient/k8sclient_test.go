@@ -0,0 +1,108 @@
+package k8sclient
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/go-test/deep"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestResolveContexts(t *testing.T) {
+	kubeConfig := clientcmdapi.NewConfig()
+	for _, name := range []string{
+		"dev",
+		"prod-a",
+		"prod-b",
+		"arn:aws:eks:eu-west-1:123456789012:cluster/prod-eu",
+		"arn:aws:eks:us-east-1:123456789012:cluster/staging-us",
+	} {
+		kubeConfig.Contexts[name] = clientcmdapi.NewContext()
+	}
+
+	kubeConfigPath := filepath.Join(t.TempDir(), "config")
+	if err := clientcmd.WriteToFile(*kubeConfig, kubeConfigPath); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		patterns []string
+		all      bool
+		contexts []string
+		wantErr  bool
+	}{
+		{
+			name:     "test exact name",
+			patterns: []string{"dev"},
+			contexts: []string{"dev"},
+		},
+		{
+			name:     "test glob",
+			patterns: []string{"prod-*"},
+			contexts: []string{"prod-a", "prod-b"},
+		},
+		{
+			name:     "test duplicate matches",
+			patterns: []string{"prod-?", "prod-a"},
+			contexts: []string{"prod-a", "prod-b"},
+		},
+		{
+			name:     "test glob across slash",
+			patterns: []string{"arn:aws:eks:*"},
+			contexts: []string{
+				"arn:aws:eks:eu-west-1:123456789012:cluster/prod-eu",
+				"arn:aws:eks:us-east-1:123456789012:cluster/staging-us",
+			},
+		},
+		{
+			name:     "test glob on both sides of slash",
+			patterns: []string{"*prod*"},
+			contexts: []string{
+				"arn:aws:eks:eu-west-1:123456789012:cluster/prod-eu",
+				"prod-a",
+				"prod-b",
+			},
+		},
+		{
+			name:     "test exact name with slash",
+			patterns: []string{"arn:aws:eks:us-east-1:123456789012:cluster/staging-us"},
+			contexts: []string{"arn:aws:eks:us-east-1:123456789012:cluster/staging-us"},
+		},
+		{
+			name: "test all contexts",
+			all:  true,
+			contexts: []string{
+				"arn:aws:eks:eu-west-1:123456789012:cluster/prod-eu",
+				"arn:aws:eks:us-east-1:123456789012:cluster/staging-us",
+				"dev",
+				"prod-a",
+				"prod-b",
+			},
+		},
+		{
+			name:     "test no match",
+			patterns: []string{"staging-*"},
+			wantErr:  true,
+		},
+		{
+			name:     "test invalid pattern",
+			patterns: []string{"prod-["},
+			wantErr:  true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			contexts, err := ResolveContexts(kubeConfigPath, testCase.patterns, testCase.all)
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := deep.Equal(contexts, testCase.contexts); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
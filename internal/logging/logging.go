@@ -0,0 +1,143 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Structured fields carried by log entries.
+const (
+	FieldAction    = "action"
+	FieldCluster   = "cluster"
+	FieldNamespace = "namespace"
+	FieldResource  = "resource"
+)
+
+// Supported log formats.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Formats lists the supported log formats.
+var Formats = []string{FormatJSON, FormatText}
+
+// clusterKey is the context key under which the cluster is stored.
+type clusterKey struct{}
+
+// defaults holds the fields which are added to entries which don't set
+// them. It is set once by SetDefaults before any concurrent logging.
+var defaults = &defaultFields{fields: log.Fields{}}
+
+// Configure sets the output, level and format of the logger. quiet only
+// logs errors and cannot be combined with an explicit level.
+func Configure(logger *log.Logger, out io.Writer, level, logFormat string, quiet, levelSet bool) error {
+	if quiet && levelSet {
+		return fmt.Errorf("only one of --quiet and --log-level may be provided")
+	}
+	if quiet {
+		level = log.ErrorLevel.String()
+	}
+
+	lvl, err := log.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("--log-level: %w", err)
+	}
+
+	switch logFormat {
+	case FormatJSON:
+		logger.SetFormatter(&log.JSONFormatter{})
+	case FormatText:
+		logger.SetFormatter(&log.TextFormatter{})
+	default:
+		return fmt.Errorf("--log-format: unsupported format %q (supported: json, text)", logFormat)
+	}
+
+	logger.SetOutput(out)
+	logger.SetLevel(lvl)
+
+	hooks := make(log.LevelHooks)
+	hooks.Add(defaults)
+	logger.ReplaceHooks(hooks)
+
+	return nil
+}
+
+// JSON returns true if the logger writes JSON.
+func JSON(logger *log.Logger) bool {
+	_, ok := logger.Formatter.(*log.JSONFormatter)
+
+	return ok
+}
+
+// SetDefaults sets the cluster and namespace which are added to entries
+// which don't carry their own. Empty values are omitted.
+func SetDefaults(cluster, namespace string) {
+	fields := log.Fields{}
+	if cluster != "" {
+		fields[FieldCluster] = cluster
+	}
+	if namespace != "" {
+		fields[FieldNamespace] = namespace
+	}
+
+	defaults.fields = fields
+}
+
+// WithCluster returns a context whose log entries are attributed to the
+// cluster. Empty clusters are ignored.
+func WithCluster(ctx context.Context, cluster string) context.Context {
+	if cluster == "" {
+		return ctx
+	}
+
+	return context.WithValue(ctx, clusterKey{}, cluster)
+}
+
+// Cluster returns the cluster which the context's log entries are
+// attributed to, if any.
+func Cluster(ctx context.Context) (string, bool) {
+	cluster, ok := ctx.Value(clusterKey{}).(string)
+
+	return cluster, ok
+}
+
+// Resource returns a log entry for an action on a resource.
+func Resource(ctx context.Context, namespace, kind, name, action string) *log.Entry {
+	return log.WithContext(ctx).WithFields(log.Fields{
+		FieldAction:    action,
+		FieldNamespace: namespace,
+		FieldResource:  kind + "/" + name,
+	})
+}
+
+// defaultFields is a hook which adds the default fields, and the cluster
+// of the entry's context, to entries which don't set them.
+type defaultFields struct {
+	fields log.Fields
+}
+
+func (h *defaultFields) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h *defaultFields) Fire(entry *log.Entry) error {
+	if entry.Context != nil {
+		if cluster, ok := Cluster(entry.Context); ok {
+			if _, set := entry.Data[FieldCluster]; !set {
+				entry.Data[FieldCluster] = cluster
+			}
+		}
+	}
+
+	for k, v := range h.fields {
+		if _, set := entry.Data[k]; !set {
+			entry.Data[k] = v
+		}
+	}
+
+	return nil
+}
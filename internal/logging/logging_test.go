@@ -0,0 +1,150 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-test/deep"
+	log "github.com/sirupsen/logrus"
+)
+
+func TestConfigure(t *testing.T) {
+	testCases := []struct {
+		name     string
+		level    string
+		format   string
+		quiet    bool
+		levelSet bool
+		output   log.Level
+		wantErr  bool
+	}{
+		{
+			name:   "test defaults",
+			level:  "info",
+			format: FormatText,
+			output: log.InfoLevel,
+		},
+		{
+			name:     "test debug json",
+			level:    "debug",
+			format:   FormatJSON,
+			levelSet: true,
+			output:   log.DebugLevel,
+		},
+		{
+			name:   "test quiet",
+			level:  "info",
+			format: FormatText,
+			quiet:  true,
+			output: log.ErrorLevel,
+		},
+		{
+			name:     "test quiet with level",
+			level:    "debug",
+			format:   FormatText,
+			quiet:    true,
+			levelSet: true,
+			wantErr:  true,
+		},
+		{
+			name:    "test invalid level",
+			level:   "loud",
+			format:  FormatText,
+			wantErr: true,
+		},
+		{
+			name:    "test invalid format",
+			level:   "info",
+			format:  "xml",
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			logger := log.New()
+			err := Configure(logger, &bytes.Buffer{}, testCase.level, testCase.format, testCase.quiet, testCase.levelSet)
+			if testCase.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if logger.GetLevel() != testCase.output {
+				t.Errorf("expected level %s, got %s", testCase.output, logger.GetLevel())
+			}
+			if JSON(logger) != (testCase.format == FormatJSON) {
+				t.Errorf("expected format %s, got JSON %t", testCase.format, JSON(logger))
+			}
+		})
+	}
+}
+
+func TestFields(t *testing.T) {
+	testCases := []struct {
+		name   string
+		log    func(logger *log.Logger)
+		output map[string]string
+	}{
+		{
+			name: "test defaults",
+			log: func(logger *log.Logger) {
+				logger.Info("hello")
+			},
+			output: map[string]string{
+				"cluster":   "prod-a",
+				"level":     "info",
+				"msg":       "hello",
+				"namespace": "default",
+			},
+		},
+		{
+			name: "test resource",
+			log: func(logger *log.Logger) {
+				logger.WithContext(WithCluster(context.Background(), "prod-b")).WithFields(log.Fields{
+					FieldAction:    "created",
+					FieldNamespace: "kube-system",
+					FieldResource:  "pod/sonar-debug",
+				}).Info("pod created")
+			},
+			output: map[string]string{
+				"action":    "created",
+				"cluster":   "prod-b",
+				"level":     "info",
+				"msg":       "pod created",
+				"namespace": "kube-system",
+				"resource":  "pod/sonar-debug",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := log.New()
+			if err := Configure(logger, &buf, "info", FormatJSON, false, false); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			SetDefaults("prod-a", "default")
+			defer SetDefaults("", "")
+
+			testCase.log(logger)
+
+			var entry map[string]string
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			delete(entry, "time")
+
+			if diff := deep.Equal(entry, testCase.output); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
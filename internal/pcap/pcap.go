@@ -0,0 +1,50 @@
+package pcap
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+)
+
+// sessionNameMaxLength is the maximum length of a generated session name,
+// matching the limit on user-provided names.
+const sessionNameMaxLength = 50
+
+// validNode matches node names which can be used in a session name.
+var validNode = regexp.MustCompile("^[a-zA-Z0-9-.]*$")
+
+// SessionName returns the name of the capture session on the node. Node
+// names which can't be used in a session name are hashed.
+func SessionName(node string) string {
+	name := "capture-" + node
+	if len(name) <= sessionNameMaxLength && validNode.MatchString(node) {
+		return name
+	}
+
+	sum := sha256.Sum256([]byte(node))
+
+	return "capture-" + hex.EncodeToString(sum[:])[:16]
+}
+
+// PodFilter returns a BPF filter which limits a capture to the traffic of
+// the pod with the provided IP, combined with the user-provided filter.
+func PodFilter(podIP, filter string) string {
+	hostFilter := fmt.Sprintf("host %s", podIP)
+	if filter == "" {
+		return hostFilter
+	}
+
+	return fmt.Sprintf("(%s) and (%s)", hostFilter, filter)
+}
+
+// Command returns the tcpdump command which writes a packet-buffered pcap
+// stream to stdout.
+func Command(iface, filter string) []string {
+	command := []string{"tcpdump", "-i", iface, "-U", "-w", "-"}
+	if filter != "" {
+		command = append(command, filter)
+	}
+
+	return command
+}
@@ -0,0 +1,108 @@
+package pcap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestSessionName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		node     string
+		expected string
+	}{
+		{
+			name:     "test short node name",
+			node:     "worker10",
+			expected: "capture-worker10",
+		},
+		{
+			name:     "test fqdn node name",
+			node:     "ip-10-0-1-23.eu-west-1.compute.internal",
+			expected: "capture-ip-10-0-1-23.eu-west-1.compute.internal",
+		},
+		{
+			name:     "test long node name",
+			node:     strings.Repeat("a", 43),
+			expected: "capture-66d34fba71f8f450",
+		},
+		{
+			name:     "test invalid node name",
+			node:     "worker_10",
+			expected: "capture-6717b6a2033c6a0a",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			name := SessionName(testCase.node)
+
+			if diff := deep.Equal(name, testCase.expected); diff != nil {
+				t.Error(diff)
+			}
+			if len(name) > sessionNameMaxLength {
+				t.Errorf("session name %q is longer than %d characters", name, sessionNameMaxLength)
+			}
+		})
+	}
+}
+
+func TestPodFilter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		podIP    string
+		filter   string
+		expected string
+	}{
+		{
+			name:     "test no filter",
+			podIP:    "10.0.1.23",
+			expected: "host 10.0.1.23",
+		},
+		{
+			name:     "test filter",
+			podIP:    "10.0.1.23",
+			filter:   "tcp port 443 or udp port 53",
+			expected: "(host 10.0.1.23) and (tcp port 443 or udp port 53)",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if diff := deep.Equal(PodFilter(testCase.podIP, testCase.filter), testCase.expected); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestCommand(t *testing.T) {
+	testCases := []struct {
+		name     string
+		iface    string
+		filter   string
+		expected []string
+	}{
+		{
+			name:     "test no filter",
+			iface:    "any",
+			expected: []string{"tcpdump", "-i", "any", "-U", "-w", "-"},
+		},
+		{
+			name:     "test filter",
+			iface:    "eth0",
+			filter:   "udp port 53",
+			expected: []string{"tcpdump", "-i", "eth0", "-U", "-w", "-", "udp port 53"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if diff := deep.Equal(Command(testCase.iface, testCase.filter), testCase.expected); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package recording
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Kinds of event in an asciinema v2 recording.
+const (
+	eventInput  = "i"
+	eventOutput = "o"
+	eventResize = "r"
+)
+
+// header is the first line of an asciinema v2 recording.
+type header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Recorder writes a terminal session to w in asciinema v2 format. It is
+// safe for concurrent use by the input, output and resize streams.
+type Recorder struct {
+	mu    sync.Mutex
+	now   func() time.Time
+	start time.Time
+	w     io.Writer
+}
+
+// New writes the recording's header to w and returns a Recorder for the
+// rest of the session.
+func New(w io.Writer, width, height int, title string, env map[string]string) (*Recorder, error) {
+	return newRecorder(w, width, height, title, env, time.Now)
+}
+
+func newRecorder(w io.Writer, width, height int, title string, env map[string]string, now func() time.Time) (*Recorder, error) {
+	r := &Recorder{
+		now:   now,
+		start: now(),
+		w:     w,
+	}
+
+	data, err := json.Marshal(header{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: r.start.Unix(),
+		Title:     title,
+		Env:       env,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := fmt.Fprintf(w, "%s\n", data); err != nil {
+		return nil, fmt.Errorf("could not write recording: %w", err)
+	}
+
+	return r, nil
+}
+
+// Input returns a Reader which records everything read from rd.
+func (r *Recorder) Input(rd io.Reader) io.Reader {
+	return io.TeeReader(rd, &stream{kind: eventInput, r: r})
+}
+
+// Output returns a Writer which records everything written to w.
+func (r *Recorder) Output(w io.Writer) io.Writer {
+	return io.MultiWriter(w, &stream{kind: eventOutput, r: r})
+}
+
+// Resize records a change of the terminal's size.
+func (r *Recorder) Resize(width, height int) error {
+	return r.event(eventResize, fmt.Sprintf("%dx%d", width, height))
+}
+
+// event writes a single event, timed relative to the start of the
+// recording.
+func (r *Recorder) event(kind, data string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	elapsed := r.now().Sub(r.start).Seconds()
+	line, err := json.Marshal([]any{json.Number(fmt.Sprintf("%.6f", elapsed)), kind, data})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(r.w, "%s\n", line)
+	return err
+}
+
+// stream records the data written to it as events of one kind. Multi-byte
+// characters which are split across writes are held back until they are
+// complete, as events must be valid UTF-8.
+type stream struct {
+	kind    string
+	pending []byte
+	r       *Recorder
+}
+
+func (s *stream) Write(p []byte) (int, error) {
+	data := append(s.pending, p...)
+
+	// Find the start of the last character and hold it back if it is
+	// incomplete.
+	cut := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	s.pending = append([]byte(nil), data[cut:]...)
+
+	if cut == 0 {
+		return len(p), nil
+	}
+
+	if err := s.r.event(s.kind, string(data[:cut])); err != nil {
+		return 0, fmt.Errorf("could not write recording: %w", err)
+	}
+
+	return len(p), nil
+}
+
+// FileName returns the name of the recording of an exec session in the
+// provided directory, made up of the cluster, namespace, pod and time.
+func FileName(dir, cluster, namespace, pod string, t time.Time) string {
+	name := strings.Join([]string{cluster, namespace, pod, t.UTC().Format("20060102T150405Z")}, "_")
+
+	// Context names may contain characters which aren't valid in paths.
+	name = strings.NewReplacer("/", "-", ":", "-", "\\", "-").Replace(name)
+
+	return filepath.Join(dir, name+".cast")
+}
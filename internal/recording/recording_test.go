@@ -0,0 +1,93 @@
+package recording
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+)
+
+func TestRecorder(t *testing.T) {
+	start := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	ticks := []time.Duration{0, 500 * time.Millisecond, time.Second, 1500 * time.Millisecond, 2 * time.Second, 3 * time.Second}
+	now := func() time.Time {
+		t := start.Add(ticks[0])
+		ticks = ticks[1:]
+		return t
+	}
+
+	var out bytes.Buffer
+	r, err := newRecorder(&out, 80, 24, "prod/default/sonar-debug", map[string]string{"TERM": "xterm"}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Input is recorded as it is read.
+	if _, err := io.ReadAll(r.Input(strings.NewReader("ls\r"))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Output is passed through as well as recorded, and split multi-byte
+	// characters are held back until they are complete.
+	var terminal bytes.Buffer
+	w := r.Output(&terminal)
+	euro := []byte("€")
+	for _, p := range [][]byte{[]byte("bin\r\n"), append([]byte("cost: "), euro[:1]...), append(euro[1:], '\n')} {
+		if _, err := w.Write(p); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := r.Resize(120, 40); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		`{"version":2,"width":80,"height":24,"timestamp":1792411200,"title":"prod/default/sonar-debug","env":{"TERM":"xterm"}}`,
+		`[0.500000,"i","ls\r"]`,
+		`[1.000000,"o","bin\r\n"]`,
+		`[1.500000,"o","cost: "]`,
+		`[2.000000,"o","€\n"]`,
+		`[3.000000,"r","120x40"]`,
+	}
+
+	if diff := deep.Equal(strings.Split(strings.TrimSpace(out.String()), "\n"), want); diff != nil {
+		t.Error(diff)
+	}
+	if terminal.String() != "bin\r\ncost: €\n" {
+		t.Errorf("unexpected terminal output %q", terminal.String())
+	}
+}
+
+func TestFileName(t *testing.T) {
+	at := time.Date(2026, 10, 19, 12, 30, 5, 0, time.UTC)
+
+	testCases := []struct {
+		name    string
+		cluster string
+		output  string
+	}{
+		{
+			name:    "test simple context",
+			cluster: "prod-a",
+			output:  "casts/prod-a_default_sonar-debug-abc_20261019T123005Z.cast",
+		},
+		{
+			name:    "test context with path separators",
+			cluster: "arn:aws:eks:eu-west-1:123:cluster/prod",
+			output:  "casts/arn-aws-eks-eu-west-1-123-cluster-prod_default_sonar-debug-abc_20261019T123005Z.cast",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			name := FileName("casts", testCase.cluster, "default", "sonar-debug-abc", at)
+			if name != testCase.output {
+				t.Errorf("expected %q, got %q", testCase.output, name)
+			}
+		})
+	}
+}
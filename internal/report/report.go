@@ -0,0 +1,113 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/glitchcrab/sonar/internal/types"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// APIVersion is the schema version of result documents. Fields are
+	// only ever added within a version.
+	APIVersion = "sonar.a7d.io/v1alpha1"
+
+	// Kind is the kind of result documents.
+	Kind = "Result"
+)
+
+// Supported output formats.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// Result is a machine-readable record of what a command did to each
+// resource.
+type Result struct {
+	APIVersion string     `json:"apiVersion"`
+	Kind       string     `json:"kind"`
+	Command    string     `json:"command"`
+	Resources  []Resource `json:"resources"`
+	Errors     []string   `json:"errors,omitempty"`
+}
+
+// Resource records what happened to a single resource. Action is one of
+// created, existed, deleted, skipped or failed.
+type Resource struct {
+	Cluster   string `json:"cluster,omitempty"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Action    string `json:"action"`
+	Error     string `json:"error,omitempty"`
+}
+
+// New returns an empty result for the command.
+func New(command string) *Result {
+	return &Result{
+		APIVersion: APIVersion,
+		Kind:       Kind,
+		Command:    command,
+		Resources:  []Resource{},
+	}
+}
+
+// ValidateFormat returns an error if the output format is not supported.
+// An empty format selects human-readable output.
+func ValidateFormat(format string) error {
+	switch format {
+	case "", FormatJSON, FormatYAML:
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format %q (supported: json, yaml)", format)
+	}
+}
+
+// Add records the outcome for each resource in the cluster.
+func (r *Result) Add(cluster string, results []types.ResourceResult) {
+	for _, result := range results {
+		resource := Resource{
+			Cluster:   cluster,
+			Kind:      result.Kind,
+			Namespace: result.Namespace,
+			Name:      result.Name,
+			Action:    result.Action,
+		}
+		if result.Err != nil {
+			resource.Error = result.Err.Error()
+		}
+		r.Resources = append(r.Resources, resource)
+	}
+}
+
+// AddError records an error which doesn't belong to a single resource.
+func (r *Result) AddError(err error) {
+	if err != nil {
+		r.Errors = append(r.Errors, err.Error())
+	}
+}
+
+// Write writes the result to w in the provided format.
+func (r *Result) Write(w io.Writer, format string) error {
+	var data []byte
+	var err error
+	switch format {
+	case FormatJSON:
+		data, err = json.MarshalIndent(r, "", "  ")
+		data = append(data, '\n')
+	case FormatYAML:
+		data, err = yaml.Marshal(r)
+	default:
+		return ValidateFormat(format)
+	}
+	if err != nil {
+		return fmt.Errorf("could not encode result: %w", err)
+	}
+
+	_, err = w.Write(data)
+
+	return err
+}
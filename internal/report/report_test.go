@@ -0,0 +1,123 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/glitchcrab/sonar/internal/types"
+	"github.com/go-test/deep"
+)
+
+func TestWrite(t *testing.T) {
+	results := []types.ResourceResult{
+		{
+			SessionResource: types.SessionResource{Kind: "configmap", Namespace: "default", Name: "sonar-debug"},
+			Action:          types.ActionCreated,
+		},
+		{
+			SessionResource: types.SessionResource{Kind: "deployment", Namespace: "default", Name: "sonar-debug"},
+			Action:          types.ActionFailed,
+			Err:             errors.New("forbidden"),
+		},
+	}
+
+	testCases := []struct {
+		name    string
+		format  string
+		cluster string
+		err     error
+		output  string
+		wantErr bool
+	}{
+		{
+			name:   "test json",
+			format: FormatJSON,
+			output: `{
+  "apiVersion": "sonar.a7d.io/v1alpha1",
+  "kind": "Result",
+  "command": "create",
+  "resources": [
+    {
+      "kind": "configmap",
+      "namespace": "default",
+      "name": "sonar-debug",
+      "action": "created"
+    },
+    {
+      "kind": "deployment",
+      "namespace": "default",
+      "name": "sonar-debug",
+      "action": "failed",
+      "error": "forbidden"
+    }
+  ]
+}
+`,
+		},
+		{
+			name:    "test yaml with cluster and error",
+			format:  FormatYAML,
+			cluster: "prod-a",
+			err:     errors.New("creation failed"),
+			output: `apiVersion: sonar.a7d.io/v1alpha1
+command: create
+errors:
+- creation failed
+kind: Result
+resources:
+- action: created
+  cluster: prod-a
+  kind: configmap
+  name: sonar-debug
+  namespace: default
+- action: failed
+  cluster: prod-a
+  error: forbidden
+  kind: deployment
+  name: sonar-debug
+  namespace: default
+`,
+		},
+		{
+			name:    "test unsupported format",
+			format:  "table",
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := New("create")
+			r.Add(testCase.cluster, results)
+			r.AddError(testCase.err)
+
+			var buf bytes.Buffer
+			err := r.Write(&buf, testCase.format)
+			if testCase.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := deep.Equal(buf.String(), testCase.output); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestEmptyResources(t *testing.T) {
+	var buf bytes.Buffer
+	if err := New("destroy").Write(&buf, FormatJSON); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Contains(buf.Bytes(), []byte(`"resources": []`)) {
+		t.Errorf("expected an empty list of resources, got %s", buf.String())
+	}
+}
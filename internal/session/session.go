@@ -0,0 +1,329 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/glitchcrab/sonar/internal/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// APIVersion is the only supported session file API version.
+	APIVersion = "sonar.a7d.io/v1alpha1"
+	// Kind is the only supported session file kind.
+	Kind = "Session"
+)
+
+// Defaults used when a session file does not set a value. These match the
+// defaults of the create command's flags.
+const (
+	defaultArgs    = "24h"
+	defaultCommand = "sleep"
+	defaultGroup   = int64(1000)
+	defaultImage   = "busybox:latest"
+	defaultUser    = int64(1000)
+)
+
+// Session is a declarative description of a Sonar debug session.
+type Session struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Metadata   Metadata `json:"metadata"`
+	Spec       Spec     `json:"spec"`
+}
+
+// Metadata identifies the session.
+type Metadata struct {
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// Spec describes the debug container and its supporting resources.
+type Spec struct {
+	Args             []string         `json:"args,omitempty"`
+	Command          []string         `json:"command,omitempty"`
+	CopyPullSecret   string           `json:"copyPullSecret,omitempty"`
+	Env              []string         `json:"env,omitempty"`
+	EnvFrom          []string         `json:"envFrom,omitempty"`
+	Image            string           `json:"image,omitempty"`
+	ImagePullPolicy  string           `json:"imagePullPolicy,omitempty"`
+	ImagePullSecrets []string         `json:"imagePullSecrets,omitempty"`
+	Like             string           `json:"like,omitempty"`
+	Mounts           []string         `json:"mounts,omitempty"`
+	NetworkPolicy    NetworkPolicy    `json:"networkPolicy,omitempty"`
+	RBAC             RBAC             `json:"rbac,omitempty"`
+	Scheduling       Scheduling       `json:"scheduling,omitempty"`
+	Security         Security         `json:"security,omitempty"`
+	TTL              *metav1.Duration `json:"ttl,omitempty"`
+	// Workload is the kind of workload which runs the debug container
+	// (see config.Kinds).
+	Workload string `json:"workload,omitempty"`
+}
+
+// NetworkPolicy configures the session's NetworkPolicy.
+type NetworkPolicy struct {
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// RBAC configures which role is bound to the session's ServiceAccount. At
+// most one field may be set.
+type RBAC struct {
+	ClusterRole string   `json:"clusterRole,omitempty"`
+	ReadOnly    []string `json:"readOnly,omitempty"`
+	Role        string   `json:"role,omitempty"`
+}
+
+// Scheduling configures where the debug pod runs.
+type Scheduling struct {
+	NodeExec bool   `json:"nodeExec,omitempty"`
+	NodeName string `json:"nodeName,omitempty"`
+}
+
+// Security configures the debug container's security context.
+type Security struct {
+	NoToken             bool   `json:"noToken,omitempty"`
+	Privileged          bool   `json:"privileged,omitempty"`
+	PrivilegeEscalation bool   `json:"privilegeEscalation,omitempty"`
+	RunAsGroup          *int64 `json:"runAsGroup,omitempty"`
+	RunAsNonRoot        *bool  `json:"runAsNonRoot,omitempty"`
+	RunAsUser           *int64 `json:"runAsUser,omitempty"`
+	ServiceAccount      string `json:"serviceAccount,omitempty"`
+	UnprivilegedPing    bool   `json:"unprivilegedPing,omitempty"`
+}
+
+// Load reads, decodes and validates a session file.
+func Load(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read session file: %w", err)
+	}
+
+	return Parse(data)
+}
+
+// Parse decodes and validates a session document. Unknown fields are
+// rejected so that typos don't silently fall back to defaults.
+func Parse(data []byte) (*Session, error) {
+	s := &Session{}
+	if err := yaml.UnmarshalStrict(data, s); err != nil {
+		return nil, fmt.Errorf("could not decode session file: %w", err)
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Validate checks the session for errors. Each error is prefixed with
+// the path of the offending field.
+func (s *Session) Validate() error {
+	var errs []error
+
+	if s.APIVersion != APIVersion {
+		errs = append(errs, fmt.Errorf("apiVersion: unsupported value %q (expected %q)", s.APIVersion, APIVersion))
+	}
+
+	if s.Kind != Kind {
+		errs = append(errs, fmt.Errorf("kind: unsupported value %q (expected %q)", s.Kind, Kind))
+	}
+
+	if s.Spec.Image != "" {
+		if _, err := config.NormaliseImage(s.Spec.Image); err != nil {
+			errs = append(errs, fmt.Errorf("spec.image: %w", err))
+		}
+	}
+
+	for i, c := range s.Spec.Command {
+		if strings.TrimSpace(c) == "" {
+			errs = append(errs, fmt.Errorf("spec.command[%d]: must not be empty", i))
+		}
+	}
+
+	for i, e := range s.Spec.Env {
+		if _, _, err := config.ParseEnv(e); err != nil {
+			errs = append(errs, fmt.Errorf("spec.env[%d]: %w", i, err))
+		}
+	}
+	for i, e := range s.Spec.EnvFrom {
+		if _, _, err := config.ParseEnvFrom(e); err != nil {
+			errs = append(errs, fmt.Errorf("spec.envFrom[%d]: %w", i, err))
+		}
+	}
+	if err := config.ValidateImagePullPolicy(s.Spec.ImagePullPolicy); err != nil {
+		errs = append(errs, fmt.Errorf("spec.imagePullPolicy: %w", err))
+	}
+	if s.Spec.CopyPullSecret != "" {
+		if _, _, err := config.ParseSecretRef(s.Spec.CopyPullSecret); err != nil {
+			errs = append(errs, fmt.Errorf("spec.copyPullSecret: %w", err))
+		}
+	}
+
+	// Mounts use the same rules as create's --mount flag.
+	if _, err := config.ParseMounts(s.Spec.Mounts); err != nil {
+		errs = append(errs, fmt.Errorf("spec.mounts: %w", err))
+	}
+
+	sec := s.Spec.Security
+	if sec.RunAsUser != nil && *sec.RunAsUser < 0 {
+		errs = append(errs, fmt.Errorf("spec.security.runAsUser: must be 0 or greater"))
+	}
+	if sec.RunAsGroup != nil && *sec.RunAsGroup < 0 {
+		errs = append(errs, fmt.Errorf("spec.security.runAsGroup: must be 0 or greater"))
+	}
+	if sec.RunAsNonRoot != nil && *sec.RunAsNonRoot && sec.RunAsUser != nil && *sec.RunAsUser == 0 {
+		errs = append(errs, fmt.Errorf("spec.security.runAsNonRoot: cannot be true when spec.security.runAsUser is 0"))
+	}
+
+	rbac := s.Spec.RBAC
+	roles := 0
+	for _, set := range []bool{rbac.ClusterRole != "", rbac.Role != "", len(rbac.ReadOnly) > 0} {
+		if set {
+			roles++
+		}
+	}
+	if roles > 1 {
+		errs = append(errs, fmt.Errorf("spec.rbac: only one of clusterRole, role and readOnly may be set"))
+	}
+	if roles > 0 && sec.ServiceAccount != "" {
+		errs = append(errs, fmt.Errorf("spec.security.serviceAccount: cannot be set when spec.rbac binds a role"))
+	}
+	if roles > 0 && sec.NoToken {
+		errs = append(errs, fmt.Errorf("spec.security.noToken: cannot be true when spec.rbac binds a role"))
+	}
+
+	if s.Spec.Scheduling.NodeExec && s.Spec.Scheduling.NodeName == "" {
+		errs = append(errs, fmt.Errorf("spec.scheduling.nodeName: required when spec.scheduling.nodeExec is true"))
+	}
+
+	if s.Spec.TTL != nil && s.Spec.TTL.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("spec.ttl: must be greater than 0"))
+	}
+
+	if _, err := config.ParseKind(s.Spec.Workload); err != nil {
+		errs = append(errs, fmt.Errorf("spec.workload: %w", err))
+	}
+
+	// If there were any validation errors, return them as a single error.
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+// Globals returns a validated copy of the provided global configuration
+// with the session's name and namespace applied.
+func (s *Session) Globals(g config.Globals) (config.Globals, error) {
+	globals := config.Globals{
+		ClusterTimeout: g.ClusterTimeout,
+		KubeConfig:     g.KubeConfig,
+		KubeContext:    g.KubeContext,
+		KubeContexts:   g.KubeContexts,
+		Labels:         make(map[string]string),
+		Name:           g.Name,
+		Namespace:      g.Namespace,
+	}
+
+	// Keep resolving the namespace from the context if it wasn't
+	// explicitly provided.
+	if g.NamespaceFromContext {
+		globals.Namespace = ""
+	}
+
+	// The globals have already been validated, so an unset name has been
+	// replaced with the bare name stub. Reset it so that it isn't prefixed
+	// a second time.
+	if g.FullName == g.Name {
+		globals.Name = ""
+	}
+
+	if s.Metadata.Name != "" {
+		globals.Name = s.Metadata.Name
+	}
+	if s.Metadata.Namespace != "" {
+		globals.Namespace = s.Metadata.Namespace
+	}
+
+	if err := config.ValidateGlobalConfig(&globals); err != nil {
+		return globals, fmt.Errorf("metadata: %w", err)
+	}
+
+	return globals, nil
+}
+
+// CreateConfig maps the session onto a CreateConfig, filling in defaults
+// for any unset values.
+func (s *Session) CreateConfig(g config.Globals) config.CreateConfig {
+	c := config.CreateConfig{
+		ClusterRole:         s.Spec.RBAC.ClusterRole,
+		CopyPullSecret:      s.Spec.CopyPullSecret,
+		Env:                 s.Spec.Env,
+		EnvFrom:             s.Spec.EnvFrom,
+		FullName:            g.FullName,
+		Image:               defaultImage,
+		ImagePullPolicy:     s.Spec.ImagePullPolicy,
+		ImagePullSecrets:    s.Spec.ImagePullSecrets,
+		Kind:                s.Spec.Workload,
+		Like:                s.Spec.Like,
+		Labels:              g.Labels,
+		Name:                g.Name,
+		Namespace:           g.Namespace,
+		NetworkPolicy:       s.Spec.NetworkPolicy.Enabled,
+		NodeExec:            s.Spec.Scheduling.NodeExec,
+		NodeName:            s.Spec.Scheduling.NodeName,
+		NoToken:             s.Spec.Security.NoToken,
+		NonRoot:             true,
+		PodArgs:             defaultArgs,
+		PodCommand:          defaultCommand,
+		PodGroup:            defaultGroup,
+		PodUser:             defaultUser,
+		Privileged:          s.Spec.Security.Privileged,
+		PrivilegeEscalation: s.Spec.Security.PrivilegeEscalation,
+		ReadOnlyResources:   s.Spec.RBAC.ReadOnly,
+		Role:                s.Spec.RBAC.Role,
+		ServiceAccount:      s.Spec.Security.ServiceAccount,
+		UnprivilegedPing:    s.Spec.Security.UnprivilegedPing,
+	}
+
+	if s.Spec.Image != "" {
+		c.Image = s.Spec.Image
+	}
+
+	// The mounts have already been validated.
+	c.Mounts, _ = config.ParseMounts(s.Spec.Mounts)
+
+	// An explicit command replaces the default args as well, as they
+	// are unlikely to make sense for a different command. The lists are
+	// kept whole so that their items may contain spaces.
+	if len(s.Spec.Command) > 0 {
+		c.Command = s.Spec.Command
+		c.PodCommand = ""
+		c.PodArgs = ""
+	}
+	if len(s.Spec.Args) > 0 {
+		c.Args = s.Spec.Args
+		c.PodArgs = ""
+	}
+
+	if s.Spec.Security.RunAsGroup != nil {
+		c.PodGroup = *s.Spec.Security.RunAsGroup
+	}
+	if s.Spec.Security.RunAsNonRoot != nil {
+		c.NonRoot = *s.Spec.Security.RunAsNonRoot
+	}
+	if s.Spec.Security.RunAsUser != nil {
+		c.PodUser = *s.Spec.Security.RunAsUser
+	}
+
+	if s.Spec.TTL != nil {
+		c.TTL = s.Spec.TTL.Duration
+	}
+
+	return c
+}
@@ -0,0 +1,194 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glitchcrab/sonar/internal/config"
+	"github.com/go-test/deep"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		wantErr []string
+	}{
+		{
+			name: "test minimal session",
+			input: `
+apiVersion: sonar.a7d.io/v1alpha1
+kind: Session
+`,
+		},
+		{
+			name: "test full session",
+			input: `
+apiVersion: sonar.a7d.io/v1alpha1
+kind: Session
+metadata:
+  name: netdebug
+  namespace: kube-system
+spec:
+  image: glitchcrab/ubuntu-debug:v1.0
+  command: ["sleep"]
+  args: ["1h"]
+  mounts:
+    - configmap:app-config:/config
+    - pvc:data:/data:ro
+  security:
+    runAsNonRoot: false
+    runAsUser: 0
+  scheduling:
+    nodeName: worker10
+    nodeExec: true
+  networkPolicy:
+    enabled: true
+  rbac:
+    clusterRole: view
+  ttl: 4h
+  workload: job
+`,
+		},
+		{
+			name: "test wrong apiVersion and kind",
+			input: `
+apiVersion: v1
+kind: Pod
+`,
+			wantErr: []string{"apiVersion:", "kind:"},
+		},
+		{
+			name: "test unknown field",
+			input: `
+apiVersion: sonar.a7d.io/v1alpha1
+kind: Session
+spec:
+  imag: busybox
+`,
+			wantErr: []string{"unknown field"},
+		},
+		{
+			name: "test duplicate mount path",
+			input: `
+apiVersion: sonar.a7d.io/v1alpha1
+kind: Session
+spec:
+  mounts: ["emptydir:/tmp", "configmap:app-config:/tmp/"]
+`,
+			wantErr: []string{"spec.mounts: invalid mount \"configmap:app-config:/tmp/\": path /tmp is already mounted"},
+		},
+		{
+			name: "test invalid spec fields",
+			input: `
+apiVersion: sonar.a7d.io/v1alpha1
+kind: Session
+spec:
+  command: [""]
+  env: ["NO_VALUE"]
+  envFrom: ["pod/app"]
+  imagePullPolicy: Sometimes
+  copyPullSecret: pull-secret
+  mounts: ["hostpath:/:/host", "emptydir:/tmp", "emptydir:/tmp"]
+  security:
+    runAsNonRoot: true
+    runAsUser: 0
+    runAsGroup: -1
+    noToken: true
+  scheduling:
+    nodeExec: true
+  rbac:
+    role: debug
+    readOnly: [pods]
+  ttl: -1h
+  workload: daemonset
+`,
+			wantErr: []string{
+				"spec.command[0]:",
+				"spec.env[0]:",
+				"spec.envFrom[0]:",
+				"spec.imagePullPolicy:",
+				"spec.copyPullSecret:",
+				"spec.mounts: invalid mount \"hostpath:/:/host\"",
+				"spec.rbac:",
+				"spec.security.noToken:",
+				"spec.security.runAsGroup:",
+				"spec.security.runAsNonRoot:",
+				"spec.scheduling.nodeName:",
+				"spec.ttl:",
+				"spec.workload:",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := Parse([]byte(testCase.input))
+
+			if len(testCase.wantErr) == 0 && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(testCase.wantErr) > 0 && err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+
+			for _, want := range testCase.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected error to contain %q, got %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateConfig(t *testing.T) {
+	s, err := Parse([]byte(`
+apiVersion: sonar.a7d.io/v1alpha1
+kind: Session
+spec:
+  image: glitchcrab/ubuntu-debug:v1.0
+  imagePullPolicy: Always
+  env: ["HTTP_PROXY=http://proxy:3128"]
+  command: ["/bin/sh"]
+  args: ["-c", "echo hello world"]
+  security:
+    runAsUser: 2000
+  networkPolicy:
+    enabled: true
+  ttl: 1h
+  workload: pod
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	g := config.Globals{
+		FullName:  "sonar-test",
+		Labels:    map[string]string{"name": "test"},
+		Name:      "test",
+		Namespace: "default",
+	}
+
+	want := config.CreateConfig{
+		Args:            []string{"-c", "echo hello world"},
+		Command:         []string{"/bin/sh"},
+		FullName:        "sonar-test",
+		Env:             []string{"HTTP_PROXY=http://proxy:3128"},
+		Image:           "glitchcrab/ubuntu-debug:v1.0",
+		ImagePullPolicy: "Always",
+		Kind:            "pod",
+		Labels:          map[string]string{"name": "test"},
+		Name:            "test",
+		Namespace:       "default",
+		NetworkPolicy:   true,
+		NonRoot:         true,
+		PodGroup:        1000,
+		PodUser:         2000,
+		TTL:             time.Hour,
+	}
+
+	if diff := deep.Equal(s.CreateConfig(g), want); diff != nil {
+		t.Error(diff)
+	}
+}
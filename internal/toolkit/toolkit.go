@@ -0,0 +1,146 @@
+package toolkit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Sources of a toolkit.
+const (
+	SourceBuiltin = "built-in"
+	SourceConfig  = "config"
+)
+
+// Toolkit is a curated debug image along with how it should be run.
+// Custom toolkits are read from the 'toolkits' key of the config file.
+// Added capabilities are only effective for root, as a non-root process
+// gets no ambient capabilities, so toolkits which need them also set Root.
+type Toolkit struct {
+	Name         string   `mapstructure:"-"`
+	Description  string   `mapstructure:"description"`
+	Image        string   `mapstructure:"image"`
+	Command      string   `mapstructure:"command"`
+	Args         string   `mapstructure:"args"`
+	Capabilities []string `mapstructure:"capabilities"`
+	Root         bool     `mapstructure:"root"`
+	Flags        []string `mapstructure:"flags"`
+	Source       string   `mapstructure:"-"`
+}
+
+// builtins is the built-in catalog.
+var builtins = []Toolkit{
+	{
+		Name:        "dns",
+		Description: "DNS troubleshooting (dig, nslookup, host)",
+		Image:       "registry.k8s.io/e2e-test-images/jessie-dnsutils:1.7",
+		Command:     "sleep",
+		Args:        "24h",
+		Flags:       []string{"--networkpolicy"},
+	},
+	{
+		Name:         "jvm",
+		Description:  "JVM diagnostics (jcmd, jstack, jmap, jfr)",
+		Image:        "eclipse-temurin:21-jdk",
+		Command:      "sleep",
+		Args:         "24h",
+		Capabilities: []string{"SYS_PTRACE"},
+		Root:         true,
+		Flags:        []string{"--like deployment/<name>"},
+	},
+	{
+		Name:         "net",
+		Description:  "Network troubleshooting (tcpdump, curl, dig, iperf, nmap)",
+		Image:        "nicolaka/netshoot:latest",
+		Command:      "sleep",
+		Args:         "24h",
+		Capabilities: []string{"NET_ADMIN", "NET_RAW"},
+		Root:         true,
+		Flags:        []string{"--networkpolicy", "--unprivileged-ping"},
+	},
+	{
+		Name:        "node",
+		Description: "Node troubleshooting in the host's namespaces",
+		Image:       "glitchcrab/ubuntu-debug:latest",
+		Command:     "sleep",
+		Args:        "24h",
+		Root:        true,
+		Flags:       []string{"--node-exec", "--node-name <node>"},
+	},
+	{
+		Name:        "storage",
+		Description: "Volume inspection (df, du, find, stat)",
+		Image:       "busybox:latest",
+		Command:     "sleep",
+		Args:        "24h",
+		Flags:       []string{"--mount pvc:<name>:/data:ro"},
+	},
+}
+
+// Catalog returns the built-in toolkits merged with the custom toolkits,
+// sorted by name. Custom toolkits replace built-in toolkits of the same
+// name.
+func Catalog(custom map[string]Toolkit) []Toolkit {
+	byName := make(map[string]Toolkit)
+	for _, t := range builtins {
+		t.Source = SourceBuiltin
+		byName[t.Name] = t
+	}
+	for name, t := range custom {
+		t.Name = name
+		t.Source = SourceConfig
+		byName[name] = t
+	}
+
+	var catalog []Toolkit
+	for _, t := range byName {
+		catalog = append(catalog, t)
+	}
+	sort.Slice(catalog, func(i, j int) bool {
+		return catalog[i].Name < catalog[j].Name
+	})
+
+	return catalog
+}
+
+// Lookup returns the named toolkit from the catalog.
+func Lookup(catalog []Toolkit, name string) (Toolkit, error) {
+	var names []string
+	for _, t := range catalog {
+		if t.Name == name {
+			return t, nil
+		}
+		names = append(names, t.Name)
+	}
+
+	return Toolkit{}, fmt.Errorf("unknown toolkit %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// Validate checks that a toolkit can be used.
+func (t Toolkit) Validate() error {
+	if t.Image == "" {
+		return fmt.Errorf("toolkit %q: image must be set", t.Name)
+	}
+
+	return nil
+}
+
+// FromConfig returns the catalog including any custom toolkits from the
+// 'toolkits' key of the config file.
+func FromConfig(v *viper.Viper) ([]Toolkit, error) {
+	var custom map[string]Toolkit
+	if err := v.UnmarshalKey("toolkits", &custom); err != nil {
+		return nil, fmt.Errorf("could not decode toolkits from config file: %w", err)
+	}
+
+	catalog := Catalog(custom)
+	for _, t := range catalog {
+		if err := t.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return catalog, nil
+}
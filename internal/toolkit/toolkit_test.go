@@ -0,0 +1,42 @@
+package toolkit
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestCatalog(t *testing.T) {
+	custom := map[string]Toolkit{
+		"net": {
+			Image: "registry.example.com/netshoot:v1",
+		},
+		"kafka": {
+			Description: "Kafka client tools",
+			Image:       "registry.example.com/kafka-tools:v3",
+		},
+	}
+
+	catalog := Catalog(custom)
+
+	var names []string
+	for _, t := range catalog {
+		names = append(names, t.Name)
+	}
+	if diff := deep.Equal(names, []string{"dns", "jvm", "kafka", "net", "node", "storage"}); diff != nil {
+		t.Error(diff)
+	}
+
+	net, err := Lookup(catalog, "net")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Toolkit{Name: "net", Image: "registry.example.com/netshoot:v1", Source: SourceConfig}
+	if diff := deep.Equal(net, want); diff != nil {
+		t.Error(diff)
+	}
+
+	if _, err := Lookup(catalog, "missing"); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
@@ -1,7 +0,0 @@
-package types
-
-// DiscoveredDeployment represents a Sonar deployment.
-type DiscoveredDeployment struct {
-	Name      string
-	Namespace string
-}
@@ -6,7 +6,12 @@ import (
 
 // DiscoveredPod represents a pod which is a candidate for execing into.
 type DiscoveredPod struct {
-	Name      string
-	Namespace string
-	Status    corev1.PodPhase
+	Annotations    map[string]string
+	Context        string
+	Kind           string
+	Name           string
+	Namespace      string
+	NodeName       string
+	ServiceAccount string
+	Status         corev1.PodPhase
 }
@@ -0,0 +1,14 @@
+package types
+
+import "time"
+
+// DiscoveredSession represents the workload which runs a Sonar debug
+// container: a deployment, a job or a bare pod.
+type DiscoveredSession struct {
+	Annotations map[string]string
+	Created     time.Time
+	Kind        string
+	Labels      map[string]string
+	Name        string
+	Namespace   string
+}
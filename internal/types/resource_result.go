@@ -0,0 +1,16 @@
+package types
+
+const (
+	ActionCreated = "created"
+	ActionDeleted = "deleted"
+	ActionExisted = "existed"
+	ActionFailed  = "failed"
+	ActionSkipped = "skipped"
+)
+
+// ResourceResult records what happened to a single resource.
+type ResourceResult struct {
+	SessionResource
+	Action string
+	Err    error
+}
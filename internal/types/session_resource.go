@@ -0,0 +1,20 @@
+package types
+
+// SessionResource represents a single resource which belongs to a Sonar
+// session. Namespace is empty for cluster-scoped resources. Owner is the
+// String() form of the session's anchor if the resource is owned by it.
+type SessionResource struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Owner     string
+}
+
+// String returns the resource in kind "namespace/name" form.
+func (r SessionResource) String() string {
+	if r.Namespace == "" {
+		return r.Kind + " \"" + r.Name + "\""
+	}
+
+	return r.Kind + " \"" + r.Namespace + "/" + r.Name + "\""
+}
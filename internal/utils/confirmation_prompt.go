@@ -2,29 +2,28 @@ package utils
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
 )
 
 // ConfirmationPrompt prompts the user for confirmation before deleting a resource. It returns true if the user confirms, and false otherwise.
-func ConfirmationPrompt(resourceType, name string) bool {
-	var response string
-
-	fmt.Printf("delete %s \"%s\" [y/n]? ", resourceType, name)
-	_, err := fmt.Scanln(&response)
+func ConfirmationPrompt(resourceType, name string) (bool, error) {
+	fmt.Fprintf(os.Stderr, "delete %s \"%s\" [y/n]? ", resourceType, name)
+	response, err := readLine()
 	if err != nil {
-		log.Fatal(err)
+		return false, err
 	}
 
-	switch strings.ToLower(response) {
+	switch strings.ToLower(strings.TrimSpace(response)) {
 	case "y", "yes":
-		return true
+		return true, nil
 	case "n", "no":
 		log.Infof("not deleting %s \"%s\"", resourceType, name)
-		return false
+		return false, nil
 	default:
-		fmt.Println("unknown response, please use 'y' or 'n':")
+		fmt.Fprintln(os.Stderr, "unknown response, please use 'y' or 'n':")
 		return ConfirmationPrompt(resourceType, name)
 	}
 }
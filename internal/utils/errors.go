@@ -0,0 +1,13 @@
+package utils
+
+import "errors"
+
+var (
+	// ErrNoSessions is returned when no Sonar sessions (deployments or
+	// pods) match the search criteria.
+	ErrNoSessions = errors.New("no sessions found")
+
+	// ErrPromptAborted is returned when the user cancels a prompt, either
+	// with Ctrl-C, Ctrl-D or by providing no selection.
+	ErrPromptAborted = errors.New("prompt aborted")
+)
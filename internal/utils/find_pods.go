@@ -2,17 +2,17 @@ package utils
 
 import (
 	"context"
-	"os"
+	"fmt"
 	"strings"
 
 	sonartypes "github.com/glitchcrab/sonar/internal/types"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-
-	log "github.com/sirupsen/logrus"
 )
 
 // FindSonarPods searches for pods matching the provided labels and returns a list of discovered pods.
+// ErrNoSessions is returned if no pods were found.
 func FindSonarPods(k8sClientSet *kubernetes.Clientset, ctx context.Context, name, namespace string, searchLabels []string) ([]sonartypes.DiscoveredPod, error) {
 	// Create a label selector string from the search labels.
 	searchOpts := metav1.ListOptions{
@@ -22,27 +22,42 @@ func FindSonarPods(k8sClientSet *kubernetes.Clientset, ctx context.Context, name
 	// Get matching pods
 	pods, err := k8sClientSet.CoreV1().Pods(namespace).List(ctx, searchOpts)
 	if err != nil {
-		log.Fatalf("error listing pods: %v", err)
+		return nil, fmt.Errorf("error listing pods: %w", err)
 	}
 
 	var discoveredPods []sonartypes.DiscoveredPod
 	for _, pod := range pods.Items {
 		discoveredPods = append(discoveredPods, sonartypes.DiscoveredPod{
-			Name:      pod.Name,
-			Namespace: pod.Namespace,
-			Status:    pod.Status.Phase,
+			Annotations: pod.Annotations,
+			Kind:        SessionKind(&pod),
+			Name:        pod.Name,
+			Namespace:   pod.Namespace,
+			NodeName:    pod.Spec.NodeName,
+			Status:      pod.Status.Phase,
 		})
 	}
 
-	// Raise a clean exit if no pods found.
+	// Return a typed error if no pods were found.
 	if len(discoveredPods) == 0 {
 		if namespace == "" {
-			log.Infof("no pods found with labels %s across all namespaces", strings.Join(searchLabels, ","))
-		} else {
-			log.Infof("no pods found with labels %s in namespace %s", strings.Join(searchLabels, ","), namespace)
+			return nil, fmt.Errorf("no pods found with labels %s across all namespaces: %w", strings.Join(searchLabels, ","), ErrNoSessions)
 		}
-		os.Exit(0)
+		return nil, fmt.Errorf("no pods found with labels %s in namespace %s: %w", strings.Join(searchLabels, ","), namespace, ErrNoSessions)
 	}
 
-	return discoveredPods, err
+	return discoveredPods, nil
+}
+
+// SessionKind returns the kind of session which a pod belongs to, based on
+// its controller: "deployment", "job" or, for bare pods, "pod".
+func SessionKind(pod *corev1.Pod) string {
+	ref := metav1.GetControllerOf(pod)
+	switch {
+	case ref == nil:
+		return "pod"
+	case ref.Kind == "ReplicaSet":
+		return "deployment"
+	default:
+		return strings.ToLower(ref.Kind)
+	}
 }
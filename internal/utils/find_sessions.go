@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	sonartypes "github.com/glitchcrab/sonar/internal/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ListSonarSessions returns the deployments, jobs and bare pods matching the provided list options.
+// Pods which are managed by a controller (such as a deployment's pods) are not sessions in their own right.
+func ListSonarSessions(k8sClientSet *kubernetes.Clientset, ctx context.Context, namespace string, searchOpts metav1.ListOptions) ([]sonartypes.DiscoveredSession, error) {
+	var sessions []sonartypes.DiscoveredSession
+
+	deployments, err := k8sClientSet.AppsV1().Deployments(namespace).List(ctx, searchOpts)
+	if err != nil {
+		return nil, fmt.Errorf("error listing deployments: %w", err)
+	}
+	for _, deploy := range deployments.Items {
+		sessions = append(sessions, newSession("deployment", deploy.ObjectMeta))
+	}
+
+	jobs, err := k8sClientSet.BatchV1().Jobs(namespace).List(ctx, searchOpts)
+	if err != nil {
+		return nil, fmt.Errorf("error listing jobs: %w", err)
+	}
+	for _, job := range jobs.Items {
+		sessions = append(sessions, newSession("job", job.ObjectMeta))
+	}
+
+	pods, err := k8sClientSet.CoreV1().Pods(namespace).List(ctx, searchOpts)
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		if metav1.GetControllerOf(&pod) == nil {
+			sessions = append(sessions, newSession("pod", pod.ObjectMeta))
+		}
+	}
+
+	return sessions, nil
+}
+
+// FindSonarSessions searches for Sonar sessions matching the provided labels and returns a list of discovered sessions.
+// ErrNoSessions is returned if no sessions were found.
+func FindSonarSessions(k8sClientSet *kubernetes.Clientset, ctx context.Context, name, namespace string, searchLabels []string) ([]sonartypes.DiscoveredSession, error) {
+	// Create a label selector string from the search labels.
+	searchOpts := metav1.ListOptions{
+		LabelSelector: strings.Join(searchLabels, ","),
+	}
+
+	sessions, err := ListSonarSessions(k8sClientSet, ctx, namespace, searchOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return a typed error if no sessions were found.
+	if len(sessions) == 0 {
+		if namespace == "" {
+			return nil, fmt.Errorf("no sessions found with labels %s across all namespaces: %w", strings.Join(searchLabels, ","), ErrNoSessions)
+		}
+		return nil, fmt.Errorf("no sessions found with labels %s in namespace %s: %w", strings.Join(searchLabels, ","), namespace, ErrNoSessions)
+	}
+
+	return sessions, nil
+}
+
+// newSession returns the session run by the workload described by meta.
+func newSession(kind string, meta metav1.ObjectMeta) sonartypes.DiscoveredSession {
+	return sonartypes.DiscoveredSession{
+		Annotations: meta.Annotations,
+		Created:     meta.CreationTimestamp.Time,
+		Kind:        kind,
+		Labels:      meta.Labels,
+		Name:        meta.Name,
+		Namespace:   meta.Namespace,
+	}
+}
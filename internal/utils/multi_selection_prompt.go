@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DisplayMultiSelectionPrompt lists numbered items and prompts the user to
+// select any number of them
+func DisplayMultiSelectionPrompt(message string, itemList []string) ([]string, error) {
+	fmt.Fprintln(os.Stderr, message)
+	for i, item := range itemList {
+		fmt.Fprintf(os.Stderr, "  %d) %s\n", i+1, item)
+	}
+
+	response, err := PromptForInput("Enter the items to select (e.g. '1,3,5-7' or 'all'): ")
+	if err != nil {
+		return nil, err
+	}
+
+	indices, err := parseSelection(response, len(itemList))
+	if err != nil {
+		return nil, err
+	}
+
+	var selection []string
+	for _, i := range indices {
+		selection = append(selection, itemList[i])
+	}
+
+	return selection, nil
+}
+
+// parseSelection converts a comma-separated list of 1-based item numbers
+// and ranges (or 'all') into a sorted list of unique 0-based indices.
+func parseSelection(response string, count int) ([]int, error) {
+	response = strings.TrimSpace(response)
+	if response == "" {
+		return nil, fmt.Errorf("no items selected: %w", ErrPromptAborted)
+	}
+
+	selected := make([]bool, count)
+	if strings.EqualFold(response, "all") {
+		for i := range selected {
+			selected[i] = true
+		}
+	} else {
+		for _, field := range strings.Split(response, ",") {
+			field = strings.TrimSpace(field)
+
+			// Each field is either a single item or a range of items.
+			first, last, isRange := strings.Cut(field, "-")
+			start, err := strconv.Atoi(strings.TrimSpace(first))
+			if err != nil {
+				return nil, fmt.Errorf("invalid selection %q", field)
+			}
+			end := start
+			if isRange {
+				end, err = strconv.Atoi(strings.TrimSpace(last))
+				if err != nil {
+					return nil, fmt.Errorf("invalid selection %q", field)
+				}
+			}
+
+			if start < 1 || end > count || start > end {
+				return nil, fmt.Errorf("selection %q is out of range (1-%d)", field, count)
+			}
+
+			for i := start; i <= end; i++ {
+				selected[i-1] = true
+			}
+		}
+	}
+
+	var indices []int
+	for i, ok := range selected {
+		if ok {
+			indices = append(indices, i)
+		}
+	}
+
+	return indices, nil
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestParseSelection(t *testing.T) {
+	testCases := []struct {
+		name     string
+		response string
+		output   []int
+		wantErr  bool
+	}{
+		{
+			name:     "test single item",
+			response: "2",
+			output:   []int{1},
+		},
+		{
+			name:     "test list and range",
+			response: "4, 1-2,2",
+			output:   []int{0, 1, 3},
+		},
+		{
+			name:     "test all",
+			response: "ALL",
+			output:   []int{0, 1, 2, 3},
+		},
+		{
+			name:     "test empty response",
+			response: " ",
+			wantErr:  true,
+		},
+		{
+			name:     "test out of range",
+			response: "0-5",
+			wantErr:  true,
+		},
+		{
+			name:     "test invalid item",
+			response: "one",
+			wantErr:  true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := parseSelection(testCase.response, 4)
+			gotErr := err != nil
+
+			if gotErr != testCase.wantErr {
+				t.Errorf("error expected: %t, got %t, %v", testCase.wantErr, gotErr, err)
+			}
+
+			if diff := deep.Equal(got, testCase.output); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
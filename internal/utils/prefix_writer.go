@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// prefixColours are the ANSI colours used to tell prefixes apart.
+var prefixColours = []string{"31", "32", "33", "34", "35", "36"}
+
+// PrefixWriter writes whole lines to an underlying writer, each prefixed
+// with a fixed string. Several PrefixWriters may share a mutex so that
+// their lines don't interleave.
+type PrefixWriter struct {
+	buf    bytes.Buffer
+	mu     *sync.Mutex
+	prefix string
+	w      io.Writer
+}
+
+// NewPrefixWriter returns a PrefixWriter for the i-th source, prefixing
+// its lines with "[name] ". The prefix is coloured if colour is set.
+func NewPrefixWriter(w io.Writer, mu *sync.Mutex, name string, i int, colour bool) *PrefixWriter {
+	prefix := fmt.Sprintf("[%s] ", name)
+	if colour {
+		prefix = fmt.Sprintf("\x1b[%sm%s\x1b[0m", prefixColours[i%len(prefixColours)], prefix)
+	}
+
+	return &PrefixWriter{mu: mu, prefix: prefix, w: w}
+}
+
+// Write buffers p and writes every complete line.
+func (p *PrefixWriter) Write(b []byte) (int, error) {
+	p.buf.Write(b)
+
+	for {
+		i := bytes.IndexByte(p.buf.Bytes(), '\n')
+		if i < 0 {
+			break
+		}
+		if err := p.writeLine(p.buf.Next(i + 1)); err != nil {
+			return len(b), err
+		}
+	}
+
+	return len(b), nil
+}
+
+// Flush writes any incomplete final line.
+func (p *PrefixWriter) Flush() error {
+	if p.buf.Len() == 0 {
+		return nil
+	}
+
+	line := append(p.buf.Next(p.buf.Len()), '\n')
+	return p.writeLine(line)
+}
+
+func (p *PrefixWriter) writeLine(line []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, err := fmt.Fprintf(p.w, "%s%s", p.prefix, line)
+	return err
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	testCases := []struct {
+		name   string
+		writes []string
+		colour bool
+		output string
+	}{
+		{
+			name:   "test whole lines",
+			writes: []string{"one\ntwo\n"},
+			output: "[pod] one\n[pod] two\n",
+		},
+		{
+			name:   "test lines split across writes",
+			writes: []string{"o", "ne\ntw", "o\n"},
+			output: "[pod] one\n[pod] two\n",
+		},
+		{
+			name:   "test incomplete final line",
+			writes: []string{"one\ntwo"},
+			output: "[pod] one\n[pod] two\n",
+		},
+		{
+			name:   "test colour",
+			writes: []string{"one\n"},
+			colour: true,
+			output: "\x1b[32m[pod] \x1b[0mone\n",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var out bytes.Buffer
+			var mu sync.Mutex
+			w := NewPrefixWriter(&out, &mu, "pod", 1, testCase.colour)
+
+			for _, s := range testCase.writes {
+				if _, err := w.Write([]byte(s)); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if out.String() != testCase.output {
+				t.Errorf("expected %q, got %q", testCase.output, out.String())
+			}
+		})
+	}
+}
@@ -2,24 +2,53 @@ package utils
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-
-	log "github.com/sirupsen/logrus"
+	"os/signal"
+	"strings"
 )
 
+// stdin is shared between prompts so that buffered input isn't lost.
+var stdin = bufio.NewReader(os.Stdin)
+
 // PromptForInput prompts the user for input and returns the response
 func PromptForInput(promptText string) (string, error) {
-	var response string
-
-	fmt.Println(promptText)
-	scanner := bufio.NewScanner(os.Stdin)
-	scanner.Scan()
-	err := scanner.Err()
-	if err != nil {
-		log.Fatal(err)
+	fmt.Fprintln(os.Stderr, promptText)
+
+	return readLine()
+}
+
+// readLine reads a single line from stdin. Ctrl-C (or the end of the
+// input) cancels the read and returns ErrPromptAborted rather than
+// terminating the process.
+func readLine() (string, error) {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	type line struct {
+		text string
+		err  error
 	}
-	response = scanner.Text()
 
-	return response, err
+	lines := make(chan line, 1)
+	go func() {
+		text, err := stdin.ReadString('\n')
+		lines <- line{text: text, err: err}
+	}()
+
+	select {
+	case <-interrupt:
+		fmt.Fprintln(os.Stderr)
+		return "", ErrPromptAborted
+	case l := <-lines:
+		if errors.Is(l.err, io.EOF) && l.text == "" {
+			return "", ErrPromptAborted
+		} else if l.err != nil && !errors.Is(l.err, io.EOF) {
+			return "", l.err
+		}
+		return strings.TrimRight(l.text, "\r\n"), nil
+	}
 }
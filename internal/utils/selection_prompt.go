@@ -1,7 +1,8 @@
 package utils
 
 import (
-	"fmt"
+	"errors"
+	"os"
 
 	"github.com/manifoldco/promptui"
 )
@@ -13,12 +14,14 @@ func DisplaySelectionPrompt(message string, itemList []string) (selection string
 		HideSelected: true,
 		Label:        message,
 		Items:        itemList,
+		Stdout:       os.Stderr,
 	}
 
 	_, selection, err = prompt.Run()
-	if err != nil {
-		fmt.Printf("Prompt failed %v\n", err)
-		return
+	if errors.Is(err, promptui.ErrInterrupt) || errors.Is(err, promptui.ErrEOF) {
+		return "", ErrPromptAborted
+	} else if err != nil {
+		return "", err
 	}
 
 	return selection, nil
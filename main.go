@@ -15,9 +15,12 @@ limitations under the License.
 */
 package main
 
-import "github.com/glitchcrab/sonar/cmd"
+import (
+	"os"
+
+	"github.com/glitchcrab/sonar/cmd"
+)
 
 func main() {
-	//nolint:errcheck
-	cmd.NewRootCommand().Execute()
+	os.Exit(cmd.Execute())
 }